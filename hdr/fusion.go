@@ -0,0 +1,256 @@
+// Copyright (c) 2017 "Shun Yokota" All rights reserved
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdr
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// minPyramidSize is the size below which pyramids are not reduced further.
+const minPyramidSize = 8
+
+// FusionOptions specifies the weights of the quality measures used by Fuse.
+// A measure is ignored if its weight is 0.
+type FusionOptions struct {
+	Contrast   float64 // favors detail, measured by a Laplacian filter
+	Saturation float64 // favors vivid colors
+	Exposure   float64 // favors values which are neither dark nor bright
+}
+
+// DefaultFusionOptions weighs all quality measures equally, as proposed by
+// Mertens et al.
+var DefaultFusionOptions = FusionOptions{Contrast: 1, Saturation: 1, Exposure: 1}
+
+// Fuse blends images of the same scene shot with different exposures into a
+// single well exposed image with Mertens exposure fusion. Each pixel is
+// weighted by its contrast, saturation and well-exposedness, and the images are
+// blended with Laplacian pyramids to avoid halos. The exposure times of the
+// images are not needed. If opt is nil, DefaultFusionOptions is used.
+func Fuse(images []image.Image, opt *FusionOptions) (*image.RGBA, error) {
+	w, h, err := sameSize(images)
+	if err != nil {
+		return nil, err
+	}
+	if opt == nil {
+		opt = &DefaultFusionOptions
+	}
+
+	levels := pyramidLevels(w, h)
+	var result [3][]*plane
+	weights := make([]*plane, len(images))
+	channels := make([][3]*plane, len(images))
+	for i, img := range images {
+		channels[i] = rgbPlanes(img)
+		weights[i] = qualityWeight(channels[i], opt)
+	}
+
+	// Normalize the weights so that they add up to 1 at each pixel.
+	weightSum := newPlane(w, h)
+	for _, wt := range weights {
+		for i, v := range wt.pix {
+			weightSum.pix[i] += v
+		}
+	}
+	for _, wt := range weights {
+		for i := range wt.pix {
+			wt.pix[i] /= weightSum.pix[i]
+		}
+	}
+
+	for i := range images {
+		gw := gaussianPyramid(weights[i], levels)
+		for c := 0; c < 3; c++ {
+			lp := laplacianPyramid(channels[i][c], levels)
+			if result[c] == nil {
+				result[c] = make([]*plane, levels)
+				for l := range lp {
+					result[c][l] = newPlane(lp[l].w, lp[l].h)
+				}
+			}
+			for l := range lp {
+				r, lpix, wpix := result[c][l].pix, lp[l].pix, gw[l].pix
+				for j := range r {
+					r[j] += lpix[j] * wpix[j]
+				}
+			}
+		}
+		channels[i] = [3]*plane{} // release memory as early as possible
+	}
+
+	out := image.NewRGBA(image.Rect(0, 0, w, h))
+	var rgb [3]*plane
+	for c := 0; c < 3; c++ {
+		rgb[c] = collapse(result[c])
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			i := y*w + x
+			out.SetRGBA(x, y, color.RGBA{
+				R: uint8(clamp01(rgb[0].pix[i])*255 + 0.5),
+				G: uint8(clamp01(rgb[1].pix[i])*255 + 0.5),
+				B: uint8(clamp01(rgb[2].pix[i])*255 + 0.5),
+				A: 255,
+			})
+		}
+	}
+	return out, nil
+}
+
+// qualityWeight returns the weight of each pixel of an image from its contrast,
+// saturation and well-exposedness.
+func qualityWeight(rgb [3]*plane, opt *FusionOptions) *plane {
+	w, h := rgb[0].w, rgb[0].h
+	gray := newPlane(w, h)
+	for i := range gray.pix {
+		gray.pix[i] = 0.299*rgb[0].pix[i] + 0.587*rgb[1].pix[i] + 0.114*rgb[2].pix[i]
+	}
+
+	weight := newPlane(w, h)
+	const sigma = 0.2
+	for y := 0; y < h; y++ {
+		up, down := clampInt(y-1, h), clampInt(y+1, h)
+		for x := 0; x < w; x++ {
+			i := y*w + x
+			left, right := wrapInt(x-1, w), wrapInt(x+1, w)
+			wt := 1.0
+
+			if opt.Contrast != 0 {
+				lap := gray.pix[up*w+x] + gray.pix[down*w+x] + gray.pix[y*w+left] + gray.pix[y*w+right] - 4*gray.pix[i]
+				wt *= math.Pow(math.Abs(float64(lap)), opt.Contrast)
+			}
+
+			r, g, b := float64(rgb[0].pix[i]), float64(rgb[1].pix[i]), float64(rgb[2].pix[i])
+			if opt.Saturation != 0 {
+				mean := (r + g + b) / 3
+				sd := math.Sqrt(((r-mean)*(r-mean) + (g-mean)*(g-mean) + (b-mean)*(b-mean)) / 3)
+				wt *= math.Pow(sd, opt.Saturation)
+			}
+			if opt.Exposure != 0 {
+				e := math.Exp(-((r-0.5)*(r-0.5) + (g-0.5)*(g-0.5) + (b-0.5)*(b-0.5)) / (2 * sigma * sigma))
+				wt *= math.Pow(e, opt.Exposure)
+			}
+			// Keep every weight positive so that flat areas are averaged.
+			weight.pix[i] = float32(wt) + 1e-12
+		}
+	}
+	return weight
+}
+
+// pyramidLevels returns the number of levels of the pyramids of an image.
+func pyramidLevels(w, h int) int {
+	levels := 1
+	for w > minPyramidSize && h > minPyramidSize {
+		w, h = (w+1)/2, (h+1)/2
+		levels++
+	}
+	return levels
+}
+
+func gaussianPyramid(p *plane, levels int) []*plane {
+	pyr := []*plane{p}
+	for len(pyr) < levels {
+		pyr = append(pyr, reduce(pyr[len(pyr)-1]))
+	}
+	return pyr
+}
+
+func laplacianPyramid(p *plane, levels int) []*plane {
+	g := gaussianPyramid(p, levels)
+	for l := 0; l < levels-1; l++ {
+		up := expand(g[l+1], g[l].w, g[l].h)
+		for i := range g[l].pix {
+			g[l].pix[i] -= up.pix[i]
+		}
+	}
+	return g
+}
+
+// collapse rebuilds an image from its Laplacian pyramid.
+func collapse(pyr []*plane) *plane {
+	r := pyr[len(pyr)-1]
+	for l := len(pyr) - 2; l >= 0; l-- {
+		up := expand(r, pyr[l].w, pyr[l].h)
+		for i := range up.pix {
+			up.pix[i] += pyr[l].pix[i]
+		}
+		r = up
+	}
+	return r
+}
+
+// kernel is the binomial filter used to build pyramids.
+var kernel = [5]float32{1.0 / 16, 4.0 / 16, 6.0 / 16, 4.0 / 16, 1.0 / 16}
+
+// reduce blurs p and halves its size.
+func reduce(p *plane) *plane {
+	b := blur(p)
+	w, h := (p.w+1)/2, (p.h+1)/2
+	r := newPlane(w, h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			r.pix[y*w+x] = b.pix[2*y*p.w+2*x]
+		}
+	}
+	return r
+}
+
+// expand doubles the size of p to w x h and smooths it.
+func expand(p *plane, w, h int) *plane {
+	e := newPlane(w, h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			e.pix[y*w+x] = p.pix[(y/2)*p.w+x/2]
+		}
+	}
+	return blur(e)
+}
+
+// blur applies kernel horizontally, wrapping around, and vertically, clamping
+// at the top and bottom.
+func blur(p *plane) *plane {
+	tmp := newPlane(p.w, p.h)
+	for y := 0; y < p.h; y++ {
+		row := p.pix[y*p.w : (y+1)*p.w]
+		for x := 0; x < p.w; x++ {
+			var s float32
+			for k, kv := range kernel {
+				s += kv * row[wrapInt(x+k-2, p.w)]
+			}
+			tmp.pix[y*p.w+x] = s
+		}
+	}
+	out := newPlane(p.w, p.h)
+	for y := 0; y < p.h; y++ {
+		for x := 0; x < p.w; x++ {
+			var s float32
+			for k, kv := range kernel {
+				s += kv * tmp.pix[clampInt(y+k-2, p.h)*p.w+x]
+			}
+			out.pix[y*p.w+x] = s
+		}
+	}
+	return out
+}
+
+func wrapInt(x, n int) int {
+	x %= n
+	if x < 0 {
+		x += n
+	}
+	return x
+}
+
+func clampInt(x, n int) int {
+	switch {
+	case x < 0:
+		return 0
+	case x >= n:
+		return n - 1
+	}
+	return x
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2017 "Shun Yokota" All rights reserved
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package hdr merges the shots of an auto bracket into a single image.
+//
+// Fuse blends the shots with Mertens exposure fusion into an image which can be
+// saved as JPEG directly. Merge combines them into a high dynamic range
+// Radiance, which can be saved as a Radiance .hdr file for tone mapping in
+// other tools. Both run on the CPU only.
+//
+// The images are treated as equirectangular: filters wrap around horizontally,
+// so no seam appears where the left and right edges meet.
+package hdr
+
+import (
+	"errors"
+	"image"
+
+	"github.com/y0k0ta19/go-theta/theta"
+)
+
+// Exposure is a shot of an auto bracket with the exposure it was taken with.
+type Exposure struct {
+	Image        image.Image
+	ExposureTime float64 // seconds
+	ISO          int
+}
+
+// NewExposure returns the exposure of img, taking the exposure time and ISO
+// speed from its EXIF metadata, as returned by theta.CommandServices.GetMetadata.
+func NewExposure(img image.Image, exif *theta.EXIF) (*Exposure, error) {
+	if exif == nil || exif.ExposureTime == nil || exif.ISOSpeedRatings == nil {
+		return nil, errors.New("hdr: EXIF has no ExposureTime or ISOSpeedRatings")
+	}
+	return &Exposure{
+		Image:        img,
+		ExposureTime: *exif.ExposureTime,
+		ISO:          *exif.ISOSpeedRatings,
+	}, nil
+}
+
+// plane is a single channel image of float32 values.
+type plane struct {
+	w, h int
+	pix  []float32
+}
+
+func newPlane(w, h int) *plane {
+	return &plane{w: w, h: h, pix: make([]float32, w*h)}
+}
+
+// rgbPlanes splits img into its red, green and blue channels in [0, 1]. The
+// values are gamma-encoded as in the image.
+func rgbPlanes(img image.Image) [3]*plane {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	var p [3]*plane
+	for c := range p {
+		p[c] = newPlane(w, h)
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			r, g, bl, _ := img.At(b.Min.X+x, b.Min.Y+y).RGBA()
+			i := y*w + x
+			p[0].pix[i] = float32(r) / 0xffff
+			p[1].pix[i] = float32(g) / 0xffff
+			p[2].pix[i] = float32(bl) / 0xffff
+		}
+	}
+	return p
+}
+
+// sameSize returns the size shared by images, or an error if they differ.
+func sameSize(images []image.Image) (int, int, error) {
+	if len(images) == 0 {
+		return 0, 0, errors.New("hdr: no images")
+	}
+	b := images[0].Bounds()
+	for _, img := range images[1:] {
+		if img.Bounds().Dx() != b.Dx() || img.Bounds().Dy() != b.Dy() {
+			return 0, 0, errors.New("hdr: images differ in size")
+		}
+	}
+	return b.Dx(), b.Dy(), nil
+}
+
+// clamp01 limits v to [0, 1].
+func clamp01(v float32) float32 {
+	switch {
+	case v < 0:
+		return 0
+	case v > 1:
+		return 1
+	}
+	return v
+}
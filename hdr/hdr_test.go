@@ -0,0 +1,202 @@
+// Copyright (c) 2017 "Shun Yokota" All rights reserved
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdr
+
+import (
+	"bufio"
+	"bytes"
+	"image"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/y0k0ta19/go-theta/theta"
+)
+
+// gradient returns an image whose brightness increases from left to right.
+func gradient(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := float64(x) * 255 / float64(w-1)
+			img.SetRGBA(x, y, color.RGBA{uint8(v), uint8(v * 0.8), uint8(v * 0.6), 255})
+		}
+	}
+	return img
+}
+
+func TestNewExposure(t *testing.T) {
+	exif := &theta.EXIF{ExposureTime: theta.Float64(0.004), ISOSpeedRatings: theta.Int(200)}
+	e, err := NewExposure(nil, exif)
+	if err != nil {
+		t.Fatalf("NewExposure returned error: %v", err)
+	}
+	if e.ExposureTime != 0.004 || e.ISO != 200 {
+		t.Errorf("NewExposure returned %+v, want ExposureTime 0.004 and ISO 200", e)
+	}
+	if _, err := NewExposure(nil, &theta.EXIF{}); err == nil {
+		t.Errorf("NewExposure without ExposureTime returned no error")
+	}
+}
+
+func TestFuse(t *testing.T) {
+	img := gradient(40, 20)
+	out, err := Fuse([]image.Image{img, img}, nil)
+	if err != nil {
+		t.Fatalf("Fuse returned error: %v", err)
+	}
+	// Fusing identical images must reproduce the image.
+	for _, x := range []int{0, 10, 20, 39} {
+		got, want := out.RGBAAt(x, 10), img.RGBAAt(x, 10)
+		if d := int(got.R) - int(want.R); d < -2 || d > 2 {
+			t.Errorf("Fuse pixel at %d is %v, want %v", x, got, want)
+		}
+	}
+
+	if _, err := Fuse([]image.Image{img, gradient(20, 20)}, nil); err == nil {
+		t.Errorf("Fuse with images of different sizes returned no error")
+	}
+}
+
+func TestFuse_prefersWellExposed(t *testing.T) {
+	dark := image.NewUniform(color.RGBA{10, 10, 10, 255})
+	mid := image.NewUniform(color.RGBA{128, 128, 128, 255})
+	bright := image.NewUniform(color.RGBA{250, 250, 250, 255})
+	var images []image.Image
+	for _, u := range []*image.Uniform{dark, mid, bright} {
+		img := image.NewRGBA(image.Rect(0, 0, 16, 16))
+		for y := 0; y < 16; y++ {
+			for x := 0; x < 16; x++ {
+				img.Set(x, y, u)
+			}
+		}
+		images = append(images, img)
+	}
+	out, err := Fuse(images, &FusionOptions{Exposure: 1})
+	if err != nil {
+		t.Fatalf("Fuse returned error: %v", err)
+	}
+	if got := out.RGBAAt(8, 8).R; got < 110 || got > 150 {
+		t.Errorf("Fuse pixel is %v, want close to 128", got)
+	}
+}
+
+// exposed returns a shot of a scene whose radiance increases linearly from left
+// to right, taken with the exposure time t at ISO 100.
+func exposed(w, h int, t float64) *Exposure {
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := 255 * math.Pow(math.Min(1, sceneRadiance(x)*t), 1/gamma)
+			img.SetGray(x, y, color.Gray{uint8(v + 0.5)})
+		}
+	}
+	return &Exposure{Image: img, ExposureTime: t, ISO: 100}
+}
+
+func sceneRadiance(x int) float64 { return float64(x+1) * 4 }
+
+func TestMerge(t *testing.T) {
+	exposures := []*Exposure{exposed(32, 2, 1.0/256), exposed(32, 2, 1.0/32), exposed(32, 2, 1.0/8)}
+	r, err := Merge(exposures)
+	if err != nil {
+		t.Fatalf("Merge returned error: %v", err)
+	}
+	for x := 0; x < 32; x++ {
+		got, _, _ := r.At(x, 1)
+		if want := sceneRadiance(x); math.Abs(float64(got)-want)/want > 0.05 {
+			t.Errorf("Merge radiance at %d is %v, want about %v", x, got, want)
+		}
+	}
+
+	if _, err := Merge([]*Exposure{{Image: exposures[0].Image}}); err == nil {
+		t.Errorf("Merge without exposure time returned no error")
+	}
+}
+
+func TestRadiance_Encode(t *testing.T) {
+	for _, width := range []int{4, 300} {
+		r := &Radiance{Width: width, Height: 2, Pix: make([]float32, 3*width*2)}
+		for i := range r.Pix {
+			r.Pix[i] = float32(i/30) * 0.25
+		}
+		buf := new(bytes.Buffer)
+		if err := r.Encode(buf); err != nil {
+			t.Fatalf("Radiance.Encode returned error: %v", err)
+		}
+
+		br := bufio.NewReader(buf)
+		var header []string
+		for {
+			line, err := br.ReadString('\n')
+			if err != nil {
+				t.Fatalf("reading header returned error: %v", err)
+			}
+			header = append(header, strings.TrimSpace(line))
+			if strings.HasPrefix(line, "-Y") {
+				break
+			}
+		}
+		if got, want := header[len(header)-1], "-Y 2 +X "+strconv.Itoa(width); got != want {
+			t.Errorf("Radiance.Encode resolution is %q, want %q", got, want)
+		}
+
+		for y := 0; y < 2; y++ {
+			line := readScanline(t, br, width)
+			for x := 0; x < width; x++ {
+				want := toRGBE(r.At(x, y))
+				if got := line[4*x : 4*x+4]; !bytes.Equal(got, want[:]) {
+					t.Fatalf("width %d: pixel (%d, %d) is %v, want %v", width, x, y, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestToRGBE(t *testing.T) {
+	if got, want := toRGBE(1, 0.5, 0), [4]byte{128, 64, 0, 129}; got != want {
+		t.Errorf("toRGBE returned %v, want %v", got, want)
+	}
+	if got, want := toRGBE(0, 0, 0), [4]byte{}; got != want {
+		t.Errorf("toRGBE returned %v, want %v", got, want)
+	}
+}
+
+// readScanline decodes a flat or run-length encoded scanline into RGBE pixels.
+func readScanline(t *testing.T, br *bufio.Reader, width int) []byte {
+	line := make([]byte, 4*width)
+	if width < 8 {
+		if _, err := br.Read(line); err != nil {
+			t.Fatal(err)
+		}
+		return line
+	}
+	head := make([]byte, 4)
+	br.Read(head)
+	if head[0] != 2 || head[1] != 2 || int(head[2])<<8|int(head[3]) != width {
+		t.Fatalf("scanline header is %v", head)
+	}
+	for c := 0; c < 4; c++ {
+		for x := 0; x < width; {
+			n, _ := br.ReadByte()
+			if n > 128 {
+				v, _ := br.ReadByte()
+				for i := 0; i < int(n)-128; i++ {
+					line[4*(x+i)+c] = v
+				}
+				x += int(n) - 128
+				continue
+			}
+			for i := 0; i < int(n); i++ {
+				line[4*(x+i)+c], _ = br.ReadByte()
+			}
+			x += int(n)
+		}
+	}
+	return line
+}
@@ -0,0 +1,191 @@
+// Copyright (c) 2017 "Shun Yokota" All rights reserved
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package hdr
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"math"
+)
+
+// gamma approximates the response curve of the camera, which is assumed to be
+// the same for every shot.
+const gamma = 2.2
+
+// Radiance is a high dynamic range image. The values are relative radiances in
+// linear RGB, scaled so that an ISO 100, 1 second exposure maps 1.0 to white.
+type Radiance struct {
+	Width, Height int
+	Pix           []float32 // red, green and blue of each pixel, row by row
+}
+
+// At returns the red, green and blue radiance of the pixel at (x, y).
+func (r *Radiance) At(x, y int) (float32, float32, float32) {
+	i := 3 * (y*r.Width + x)
+	return r.Pix[i], r.Pix[i+1], r.Pix[i+2]
+}
+
+// Merge combines exposures of the same scene into a Radiance. The pixels of
+// each exposure are linearized and divided by their exposure, then averaged
+// with weights favoring mid-tones, so that clipped highlights and noisy shadows
+// contribute little. Pixels clipped in every exposure are taken from the
+// exposure closest to mid-grey.
+func Merge(exposures []*Exposure) (*Radiance, error) {
+	images := make([]image.Image, len(exposures))
+	scales := make([]float64, len(exposures))
+	for i, e := range exposures {
+		if e.ExposureTime <= 0 || e.ISO <= 0 {
+			return nil, fmt.Errorf("hdr: exposure %d has no exposure time or ISO", i)
+		}
+		images[i] = e.Image
+		scales[i] = 1 / (e.ExposureTime * float64(e.ISO) / 100)
+	}
+	w, h, err := sameSize(images)
+	if err != nil {
+		return nil, err
+	}
+
+	var linear [256]float64
+	for z := range linear {
+		linear[z] = math.Pow(float64(z)/255, gamma)
+	}
+
+	r := &Radiance{Width: w, Height: h, Pix: make([]float32, 3*w*h)}
+	samples := make([][3]uint8, len(images))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var sum, wsum [3]float64
+			best, bestDist := 0, math.Inf(1)
+			for i, img := range images {
+				b := img.Bounds()
+				cr, cg, cb, _ := img.At(b.Min.X+x, b.Min.Y+y).RGBA()
+				s := [3]uint8{uint8(cr >> 8), uint8(cg >> 8), uint8(cb >> 8)}
+				samples[i] = s
+				var dist float64
+				for c, z := range s {
+					wt := hat(z)
+					sum[c] += wt * linear[z] * scales[i]
+					wsum[c] += wt
+					dist += math.Abs(float64(z) - 127.5)
+				}
+				if dist < bestDist {
+					best, bestDist = i, dist
+				}
+			}
+			for c := 0; c < 3; c++ {
+				v := linear[samples[best][c]] * scales[best]
+				if wsum[c] > 0 {
+					v = sum[c] / wsum[c]
+				}
+				r.Pix[3*(y*w+x)+c] = float32(v)
+			}
+		}
+	}
+	return r, nil
+}
+
+// hat weighs a pixel value, from 0 for black or white to 1 for mid-grey.
+func hat(z uint8) float64 {
+	if z <= 127 {
+		return float64(z) / 127
+	}
+	return float64(255-z) / 128
+}
+
+// Encode writes r in the Radiance RGBE (.hdr) format. Scanlines are run-length
+// encoded when the width allows it.
+func (r *Radiance) Encode(w io.Writer) error {
+	if r.Width <= 0 || r.Height <= 0 || len(r.Pix) < 3*r.Width*r.Height {
+		return errors.New("hdr: invalid Radiance")
+	}
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "#?RADIANCE\nFORMAT=32-bit_rle_rgbe\n\n-Y %d +X %d\n", r.Height, r.Width)
+
+	rle := r.Width >= 8 && r.Width <= 0x7fff
+	line := make([]byte, 4*r.Width)
+	for y := 0; y < r.Height; y++ {
+		for x := 0; x < r.Width; x++ {
+			p := toRGBE(r.At(x, y))
+			if rle {
+				// Components are stored in separate runs.
+				for c := 0; c < 4; c++ {
+					line[c*r.Width+x] = p[c]
+				}
+			} else {
+				copy(line[4*x:], p[:])
+			}
+		}
+		if !rle {
+			bw.Write(line)
+			continue
+		}
+		bw.Write([]byte{2, 2, byte(r.Width >> 8), byte(r.Width)})
+		for c := 0; c < 4; c++ {
+			writeRun(bw, line[c*r.Width:(c+1)*r.Width])
+		}
+	}
+	return bw.Flush()
+}
+
+// toRGBE converts a linear RGB color to a shared exponent RGBE pixel.
+func toRGBE(r, g, b float32) [4]byte {
+	v := math.Max(float64(r), math.Max(float64(g), float64(b)))
+	if v < 1e-32 {
+		return [4]byte{}
+	}
+	frac, exp := math.Frexp(v)
+	scale := frac * 256 / v
+	return [4]byte{
+		byte(math.Max(0, float64(r)) * scale),
+		byte(math.Max(0, float64(g)) * scale),
+		byte(math.Max(0, float64(b)) * scale),
+		byte(exp + 128),
+	}
+}
+
+// minRun is the shortest run worth encoding as a run.
+const minRun = 4
+
+// writeRun run-length encodes a component of a scanline. A count byte above
+// 128 is followed by a byte repeated count-128 times, otherwise by count
+// literal bytes.
+func writeRun(w *bufio.Writer, data []byte) {
+	for cur := 0; cur < len(data); {
+		// Find the next run long enough to encode.
+		beg, run, prev := cur, 0, 0
+		for run < minRun && beg < len(data) {
+			beg += run
+			prev = run
+			run = 1
+			for beg+run < len(data) && run < 127 && data[beg+run] == data[beg] {
+				run++
+			}
+		}
+		// A short run right before it is encoded as a run as well.
+		if prev > 1 && prev == beg-cur {
+			w.WriteByte(byte(128 + prev))
+			w.WriteByte(data[cur])
+			cur = beg
+		}
+		for cur < beg {
+			n := beg - cur
+			if n > 128 {
+				n = 128
+			}
+			w.WriteByte(byte(n))
+			w.Write(data[cur : cur+n])
+			cur += n
+		}
+		if run >= minRun {
+			w.WriteByte(byte(128 + run))
+			w.WriteByte(data[beg])
+			cur += run
+		}
+	}
+}
@@ -2,20 +2,209 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
+	"image"
+	"image/jpeg"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
 
-	"github.com/google/go-github/github"
+	"github.com/y0k0ta19/go-theta/mirror"
+	"github.com/y0k0ta19/go-theta/projection"
+	"github.com/y0k0ta19/go-theta/stitch"
 	"github.com/y0k0ta19/go-theta/theta"
 )
 
+const usage = `usage: go-theta <command> [flags]
+
+commands:
+  sync     copy the files in the camera to a local directory
+  project  render an equirectangular image as a cubemap, view or little planet
+  stitch   convert a dual-fisheye image to an equirectangular image
+`
+
 func main() {
-	cc := github.NewClient(nil)
-	cc.NewRequest("", "", nil)
-	c := theta.NewClient(nil)
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
 	ctx, cancel := context.WithCancel(context.Background())
-	err := theta.Begin(ctx, c)
+	defer cancel()
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		<-interrupt
+		cancel()
+	}()
+
+	var err error
+	switch cmd, args := os.Args[1], os.Args[2:]; cmd {
+	case "sync":
+		err = runSync(ctx, args)
+	case "project":
+		err = runProject(args)
+	case "stitch":
+		err = runStitch(args)
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
 	if err != nil {
-		fmt.Println(err)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func runSync(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("sync", flag.ExitOnError)
+	dir := fs.String("dir", ".", "local directory")
+	layout := fs.String("layout", "flat", "directory layout: flat, date or group")
+	fileType := fs.String("type", theta.FileTypeAll, "file type: all, image or video")
+	concurrency := fs.Int("c", 2, "number of files downloaded at the same time")
+	del := fs.Bool("delete", false, "delete files from the camera after copying them")
+	fs.Parse(args)
+
+	layouts := map[string]mirror.Layout{"flat": mirror.Flat, "date": mirror.ByDate, "group": mirror.ByGroup}
+	l, ok := layouts[*layout]
+	if !ok {
+		return fmt.Errorf("unknown layout %q", *layout)
+	}
+
+	c := theta.NewClient(nil)
+	if err := theta.Begin(ctx, c); err != nil {
+		return err
+	}
+	s := &mirror.Syncer{
+		Client:      c,
+		Dir:         *dir,
+		Layout:      l,
+		FileType:    *fileType,
+		Concurrency: *concurrency,
+		Delete:      *del,
+	}
+	result, err := s.Run(ctx)
+	if result != nil {
+		fmt.Printf("%d downloaded, %d skipped, %d deleted, %d failed\n",
+			len(result.Downloaded), len(result.Skipped), len(result.Deleted), len(result.Failed))
+		for _, e := range result.Failed {
+			fmt.Fprintln(os.Stderr, e)
+		}
+	}
+	return err
+}
+
+func runProject(args []string) error {
+	fs := flag.NewFlagSet("project", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: go-theta project [flags] <input.jpg> <output.jpg>")
+		fmt.Fprintln(fs.Output(), "cube faces are written next to the output, e.g. output_front.jpg")
+		fs.PrintDefaults()
+	}
+	mode := fs.String("mode", "cube", "projection: cube, view or planet")
+	size := fs.Int("size", 1024, "size of cube faces and little planets")
+	width := fs.Int("width", 1920, "width of views")
+	height := fs.Int("height", 1080, "height of views")
+	yaw := fs.Float64("yaw", 0, "yaw in degrees")
+	pitch := fs.Float64("pitch", 0, "pitch in degrees")
+	roll := fs.Float64("roll", 0, "roll in degrees")
+	fov := fs.Float64("fov", 0, "field of view in degrees (90 for views, 270 for little planets if 0)")
+	quality := fs.Int("quality", jpeg.DefaultQuality, "JPEG quality")
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	in, out := fs.Arg(0), fs.Arg(1)
+
+	src, err := readImage(in)
+	if err != nil {
+		return err
+	}
+	opt := &jpeg.Options{Quality: *quality}
+
+	switch *mode {
+	case "cube":
+		ext := filepath.Ext(out)
+		for face, img := range projection.Cubemap(src, *size) {
+			name := fmt.Sprintf("%s_%v%s", strings.TrimSuffix(out, ext), projection.Face(face), ext)
+			if err := writeJPEG(name, img, opt); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "view":
+		v := projection.View{Yaw: *yaw, Pitch: *pitch, Roll: *roll, FOV: *fov, Width: *width, Height: *height}
+		img, err := projection.Perspective(src, v)
+		if err != nil {
+			return err
+		}
+		return writeJPEG(out, img, opt)
+	case "planet":
+		p := projection.Planet{Size: *size, FOV: *fov, Yaw: *yaw}
+		img, err := projection.LittlePlanet(src, p)
+		if err != nil {
+			return err
+		}
+		return writeJPEG(out, img, opt)
+	}
+	return fmt.Errorf("unknown mode %q", *mode)
+}
+
+func runStitch(args []string) error {
+	fs := flag.NewFlagSet("stitch", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: go-theta stitch [flags] <input.jpg> <output.jpg>")
+		fs.PrintDefaults()
+	}
+	model := fs.String("model", "s", "camera model: s, sc, v or z1")
+	width := fs.Int("width", 0, "width of the output, the width of the input if 0")
+	blend := fs.Float64("blend", 0, "degrees cross-faded on each side of the seam, the lens overlap if 0")
+	quality := fs.Int("quality", jpeg.DefaultQuality, "JPEG quality")
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	m, ok := stitch.ModelFor("RICOH THETA " + strings.ToUpper(*model))
+	if !ok {
+		return fmt.Errorf("unknown model %q", *model)
+	}
+	src, err := readImage(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	s := &stitch.Stitcher{Model: m, Width: *width, Blend: *blend}
+	img, err := s.Stitch(src)
+	if err != nil {
+		return err
+	}
+	return writeJPEG(fs.Arg(1), img, &jpeg.Options{Quality: *quality})
+}
+
+func readImage(name string) (image.Image, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", name, err)
+	}
+	return img, nil
+}
+
+func writeJPEG(name string, img image.Image, opt *jpeg.Options) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := jpeg.Encode(f, img, opt); err != nil {
+		f.Close()
+		return err
 	}
-	cancel()
+	return f.Close()
 }
@@ -0,0 +1,269 @@
+// Copyright (c) 2017 "Shun Yokota" All rights reserved
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package mirror copies the files in a Theta to a local directory.
+//
+// A Syncer lists the files in the camera, downloads the ones missing in the
+// local directory, and optionally deletes them from the camera once the local
+// copy has been verified. A local file is considered the same as a file in the
+// camera when its name and size match. Files are downloaded by their URL, so the client
+// must speak Theta API v2.1; see theta.Begin.
+package mirror
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+
+	"github.com/y0k0ta19/go-theta/theta"
+)
+
+// partSuffix is appended to the name of a file while it is being downloaded.
+const partSuffix = ".part"
+
+// Layout returns the directory of a file relative to Syncer.Dir. The file is
+// put in its camera folder, such as "100RICOH", below that directory, since
+// files in different folders of the camera may share a name.
+type Layout func(e *theta.Entries) string
+
+// Flat puts the camera folders directly in Syncer.Dir.
+func Flat(e *theta.Entries) string {
+	return ""
+}
+
+// ByDate puts files in a directory per shooting date, such as "2017-07-10".
+// Files without a date are put in "unknown".
+func ByDate(e *theta.Entries) string {
+	t, err := e.Time()
+	if err != nil {
+		return "unknown"
+	}
+	return t.Format("2006-01-02")
+}
+
+// ByGroup puts files shot together by interval, composite or auto bracket
+// shooting in a directory per group ID. Other files are put in Syncer.Dir.
+func ByGroup(e *theta.Entries) string {
+	for _, id := range []*string{e.IntervalCaptureGroupID, e.CompositeShootingGroupID, e.AutoBracketGroupID} {
+		if id != nil && *id != "" {
+			return *id
+		}
+	}
+	return ""
+}
+
+// Syncer copies the files in a Theta to a local directory.
+type Syncer struct {
+	Client *theta.Client
+	Dir    string // local directory
+
+	// Layout decides the directory of each file. Flat is used if nil.
+	Layout Layout
+
+	// FileType is the type of the files to copy. theta.FileTypeAll is used if
+	// empty.
+	FileType string
+
+	// Concurrency is the number of files downloaded at the same time. One file
+	// is downloaded at a time if it is 0.
+	Concurrency int
+
+	// Delete deletes each file from the camera after its local copy has been
+	// verified, including files which had been copied before.
+	Delete bool
+
+	// Progress, if set, is called while a file is downloaded. It may be called
+	// from several goroutines at once.
+	Progress func(e *theta.Entries, written, total int64)
+}
+
+// FileError reports a file which could not be copied or deleted.
+type FileError struct {
+	Entry *theta.Entries
+	Err   error
+}
+
+func (e *FileError) Error() string {
+	return fmt.Sprintf("%v: %v", e.Entry.URL(), e.Err)
+}
+
+// Result reports the files handled by Run.
+type Result struct {
+	Downloaded []*theta.Entries
+	Skipped    []*theta.Entries // already in the local directory
+	Deleted    []*theta.Entries
+	Failed     []*FileError
+}
+
+// ErrAPILevel is returned by Run when the client speaks Theta API v2.0, which
+// lists files by URIs that cannot be downloaded over HTTP.
+var ErrAPILevel = errors.New("mirror: Theta API v2.1 is required to download files")
+
+// Run copies the files. Files which fail are reported in Result.Failed and the
+// others are still copied; an error is returned if any file failed or the
+// files could not be listed.
+func (s *Syncer) Run(ctx context.Context) (*Result, error) {
+	if s.Client.APILevel() < 2 {
+		return nil, ErrAPILevel
+	}
+	fileType := s.FileType
+	if fileType == "" {
+		fileType = theta.FileTypeAll
+	}
+	var entries []*theta.Entries
+	it := s.Client.Command.Files(fileType)
+	for it.Next(ctx) {
+		if e := it.Entry(); e.Name != nil && e.URL() != "" {
+			entries = append(entries, e)
+		}
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+
+	// Two files copied to the same path would overwrite each other.
+	result := new(Result)
+	paths := make(map[string]int)
+	for _, e := range entries {
+		paths[s.Path(e)]++
+	}
+	var unique []*theta.Entries
+	for _, e := range entries {
+		if paths[s.Path(e)] > 1 {
+			result.Failed = append(result.Failed, &FileError{Entry: e, Err: errors.New("another file has the same local path")})
+			continue
+		}
+		unique = append(unique, e)
+	}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	queue := make(chan *theta.Entries)
+
+	concurrency := s.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for e := range queue {
+				downloaded, deleted, err := s.sync(ctx, e)
+
+				mu.Lock()
+				switch {
+				case err != nil:
+					result.Failed = append(result.Failed, &FileError{Entry: e, Err: err})
+				case downloaded:
+					result.Downloaded = append(result.Downloaded, e)
+				default:
+					result.Skipped = append(result.Skipped, e)
+				}
+				if deleted {
+					result.Deleted = append(result.Deleted, e)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for _, e := range unique {
+		select {
+		case queue <- e:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
+	if n := len(result.Failed); n > 0 {
+		return result, fmt.Errorf("mirror: %d of %d files failed: %v", n, len(entries), result.Failed[0])
+	}
+	return result, nil
+}
+
+// sync copies a file unless it is already in the local directory, and deletes
+// it from the camera if requested.
+func (s *Syncer) sync(ctx context.Context, e *theta.Entries) (downloaded, deleted bool, err error) {
+	name := s.Path(e)
+	size := int64(-1)
+	if e.Size != nil {
+		size = int64(*e.Size)
+	}
+
+	if !sameSize(name, size) {
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			return false, false, err
+		}
+		opt := &theta.DownloadOptions{}
+		if size >= 0 {
+			opt.Size = size
+		}
+		if s.Progress != nil {
+			opt.Progress = func(written, total int64) { s.Progress(e, written, total) }
+		}
+		part := name + partSuffix
+		if err := s.Client.DownloadToFile(ctx, e.URL(), part, opt); err != nil {
+			return false, false, err
+		}
+		if err := os.Rename(part, name); err != nil {
+			return false, false, err
+		}
+		downloaded = true
+	}
+
+	if !s.Delete {
+		return downloaded, false, nil
+	}
+	if size < 0 || !sameSize(name, size) {
+		return downloaded, false, fmt.Errorf("local copy of %v cannot be verified", *e.Name)
+	}
+	if _, err := s.Client.Command.Delete(ctx, e.URL()); err != nil {
+		return downloaded, false, err
+	}
+	return downloaded, true, nil
+}
+
+// Path returns the local path of a file in the camera.
+func (s *Syncer) Path(e *theta.Entries) string {
+	layout := s.Layout
+	if layout == nil {
+		layout = Flat
+	}
+	return filepath.Join(s.Dir, layout(e), folder(e), filepath.Base(*e.Name))
+}
+
+// folder returns the camera folder of a file, or "" if its URL has none.
+func folder(e *theta.Entries) string {
+	p := e.URL()
+	if u, err := url.Parse(p); err == nil {
+		p = u.Path
+	}
+	dir := path.Base(path.Dir(p))
+	if dir == "." || dir == "/" {
+		return ""
+	}
+	return dir
+}
+
+// sameSize reports whether the file name exists with size bytes. Any existing
+// file matches a negative size.
+func sameSize(name string, size int64) bool {
+	fi, err := os.Stat(name)
+	if err != nil || !fi.Mode().IsRegular() {
+		return false
+	}
+	return size < 0 || fi.Size() == size
+}
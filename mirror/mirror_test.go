@@ -0,0 +1,159 @@
+// Copyright (c) 2017 "Shun Yokota" All rights reserved
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mirror
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/y0k0ta19/go-theta/theta"
+)
+
+// newClient returns a client of a Theta API v2.1 camera served by mux.
+func newClient(t *testing.T, mux *http.ServeMux, server *httptest.Server) *theta.Client {
+	mux.HandleFunc("/osc/info", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"model":"RICOH THETA V","endpoints":{"httpPort":80,"apiLevel":[2]}}`)
+	})
+	client := theta.NewClient(nil)
+	client.BaseURL, _ = url.Parse(server.URL)
+	if err := theta.Begin(context.Background(), client); err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestSyncer_Run(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	var (
+		mu      sync.Mutex // guards deleted, appended by concurrent requests
+		deleted []string
+	)
+	mux.HandleFunc("/osc/commands/execute", func(w http.ResponseWriter, r *http.Request) {
+		v := new(theta.CommandRequest)
+		json.NewDecoder(r.Body).Decode(v)
+		switch *v.Name {
+		case "camera.delete":
+			mu.Lock()
+			deleted = append(deleted, v.Parameters.FileURLs...)
+			mu.Unlock()
+			fmt.Fprint(w, `{"name":"camera.delete","state":"done"}`)
+		case "camera.listFiles":
+			fmt.Fprintf(w, `{"name":"camera.listFiles","state":"done","results":{"entries":[
+				{"name":"R0010001.JPG","fileUrl":"%[1]s/files/100RICOH/R0010001.JPG","size":4,"dateTimeZone":"2017:07:10 11:05:18+09:00"},
+				{"name":"R0010002.JPG","fileUrl":"%[1]s/files/100RICOH/R0010002.JPG","size":5,"dateTimeZone":"2017:07:11 11:05:18+09:00"}
+			],"totalEntries":2}}`, server.URL)
+		}
+	})
+	mux.HandleFunc("/files/100RICOH/R0010002.JPG", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("jpeg2"))
+	})
+
+	dir, err := ioutil.TempDir("", "mirror")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// R0010001.JPG has been copied before.
+	os.MkdirAll(filepath.Join(dir, "2017-07-10", "100RICOH"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "2017-07-10", "100RICOH", "R0010001.JPG"), []byte("jpeg"), 0644)
+
+	client := newClient(t, mux, server)
+	s := &Syncer{Client: client, Dir: dir, Layout: ByDate, Concurrency: 2, Delete: true}
+	result, err := s.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if got, want := len(result.Downloaded), 1; got != want {
+		t.Errorf("Run downloaded %v files, want %v", got, want)
+	}
+	if got, want := len(result.Skipped), 1; got != want {
+		t.Errorf("Run skipped %v files, want %v", got, want)
+	}
+	if got, want := len(deleted), 2; got != want {
+		t.Errorf("Run deleted %v files, want %v", got, want)
+	}
+	if got, _ := ioutil.ReadFile(filepath.Join(dir, "2017-07-11", "100RICOH", "R0010002.JPG")); string(got) != "jpeg2" {
+		t.Errorf("R0010002.JPG has %q, want %q", got, "jpeg2")
+	}
+}
+
+func TestSyncer_Run_sameName(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	var (
+		mu      sync.Mutex // guards deleted, appended by concurrent requests
+		deleted []string
+	)
+	mux.HandleFunc("/osc/commands/execute", func(w http.ResponseWriter, r *http.Request) {
+		v := new(theta.CommandRequest)
+		json.NewDecoder(r.Body).Decode(v)
+		if *v.Name == "camera.delete" {
+			mu.Lock()
+			deleted = append(deleted, v.Parameters.FileURLs...)
+			mu.Unlock()
+			fmt.Fprint(w, `{"name":"camera.delete","state":"done"}`)
+			return
+		}
+		fmt.Fprintf(w, `{"name":"camera.listFiles","state":"done","results":{"entries":[
+			{"name":"R0010001.JPG","fileUrl":"%[1]s/files/100RICOH/R0010001.JPG","size":3},
+			{"name":"R0010001.JPG","fileUrl":"%[1]s/files/101RICOH/R0010001.JPG","size":3},
+			{"name":"R0010002.JPG","fileUrl":"%[1]s/R0010002.JPG","size":3},
+			{"name":"R0010002.JPG","fileUrl":"%[1]s/R0010002.JPG","size":3}
+		],"totalEntries":4}}`, server.URL)
+	})
+	mux.HandleFunc("/files/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path[len("/files/"):][:3]))
+	})
+
+	dir, err := ioutil.TempDir("", "mirror")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := &Syncer{Client: newClient(t, mux, server), Dir: dir, Delete: true}
+	result, err := s.Run(context.Background())
+	if err == nil {
+		t.Errorf("Run returned no error for files with the same local path")
+	}
+	if got, want := len(result.Downloaded), 2; got != want {
+		t.Errorf("Run downloaded %v files, want %v", got, want)
+	}
+	if got, want := len(result.Failed), 2; got != want {
+		t.Errorf("Run failed %v files, want %v", got, want)
+	}
+	// Files with the same local path are kept in the camera.
+	if got, want := len(deleted), 2; got != want {
+		t.Errorf("Run deleted %v files, want %v", got, want)
+	}
+	for _, f := range []string{"100", "101"} {
+		name := filepath.Join(dir, f+"RICOH", "R0010001.JPG")
+		if got, _ := ioutil.ReadFile(name); string(got) != f {
+			t.Errorf("%v has %q, want %q", name, got, f)
+		}
+	}
+}
+
+func TestSyncer_Run_v20(t *testing.T) {
+	s := &Syncer{Client: theta.NewClient(nil), Dir: "."}
+	if _, err := s.Run(context.Background()); err != ErrAPILevel {
+		t.Errorf("Run returned %v, want %v", err, ErrAPILevel)
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright (c) 2017 "Shun Yokota" All rights reserved
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package mjpeg re-broadcasts the live preview of a Theta to many HTTP clients.
+//
+// The camera serves only one live preview at a time. A Broadcaster reads that
+// single stream and serves it to any number of viewers as multipart motion
+// JPEG, which browsers show in an <img> element. A viewer which cannot keep
+// up misses frames instead of slowing down the others.
+//
+//	stream, _, err := client.Command.LivePreview(ctx)
+//	...
+//	b := mjpeg.NewBroadcaster()
+//	go b.Run(stream)
+//	http.Handle("/preview", b)
+//	http.Handle("/snapshot.jpg", b.Snapshot())
+package mjpeg
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/y0k0ta19/go-theta/theta"
+)
+
+// boundary separates the frames in the multipart stream.
+const boundary = "thetaframe"
+
+// Source is a stream of frames, such as *theta.PreviewStream.
+type Source interface {
+	Next() (theta.Frame, error)
+}
+
+// Broadcaster serves the frames of a Source to many viewers. It is an
+// http.Handler serving the multipart motion JPEG stream.
+type Broadcaster struct {
+	mu      sync.Mutex
+	viewers map[chan theta.Frame]struct{}
+	latest  theta.Frame
+	done    chan struct{} // closed when Run returns
+}
+
+// NewBroadcaster returns a new Broadcaster.
+func NewBroadcaster() *Broadcaster {
+	return &Broadcaster{
+		viewers: make(map[chan theta.Frame]struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// Run reads the frames of src and sends them to the viewers until src returns
+// an error, which Run returns. The streams of the viewers end when Run
+// returns. Run must be called only once.
+func (b *Broadcaster) Run(src Source) error {
+	defer close(b.done)
+	for {
+		f, err := src.Next()
+		if err != nil {
+			return err
+		}
+		b.publish(f)
+	}
+}
+
+func (b *Broadcaster) publish(f theta.Frame) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.latest = f
+	for ch := range b.viewers {
+		select {
+		case ch <- f:
+		default:
+			// The viewer is slow. Replace its pending frame with this one.
+			select {
+			case <-ch:
+			default:
+			}
+			ch <- f
+		}
+	}
+}
+
+// Latest returns the latest frame, or nil if no frame has been read yet.
+func (b *Broadcaster) Latest() theta.Frame {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.latest
+}
+
+func (b *Broadcaster) subscribe() chan theta.Frame {
+	ch := make(chan theta.Frame, 1)
+	b.mu.Lock()
+	b.viewers[ch] = struct{}{}
+	b.mu.Unlock()
+	return ch
+}
+
+func (b *Broadcaster) unsubscribe(ch chan theta.Frame) {
+	b.mu.Lock()
+	delete(b.viewers, ch)
+	b.mu.Unlock()
+}
+
+// ServeHTTP serves the frames as a multipart/x-mixed-replace stream until the
+// client goes away or Run returns.
+func (b *Broadcaster) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	ch := b.subscribe()
+	defer b.unsubscribe(ch)
+
+	w.Header().Set("Content-Type", "multipart/x-mixed-replace; boundary="+boundary)
+	w.Header().Set("Cache-Control", "no-cache, no-store")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-b.done:
+			return
+		case f := <-ch:
+			_, err := fmt.Fprintf(w, "--%s\r\nContent-Type: image/jpeg\r\nContent-Length: %d\r\n\r\n", boundary, len(f))
+			if err == nil {
+				_, err = w.Write(f)
+			}
+			if err == nil {
+				_, err = w.Write([]byte("\r\n"))
+			}
+			if err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// Snapshot returns a handler serving the latest frame as a JPEG image. It
+// responds with 503 Service Unavailable until the first frame has been read.
+func (b *Broadcaster) Snapshot() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f := b.Latest()
+		if f == nil {
+			http.Error(w, "no frame yet", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "image/jpeg")
+		w.Header().Set("Cache-Control", "no-cache, no-store")
+		w.Write(f)
+	})
+}
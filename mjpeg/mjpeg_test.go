@@ -0,0 +1,103 @@
+// Copyright (c) 2017 "Shun Yokota" All rights reserved
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mjpeg
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/y0k0ta19/go-theta/theta"
+)
+
+// frame is the smallest byte sequence FrameReader accepts as a frame.
+var frame = theta.Frame{0xff, 0xd8, 0xff, 0xd9}
+
+// chanSource sends the frames received from a channel.
+type chanSource chan theta.Frame
+
+func (s chanSource) Next() (theta.Frame, error) {
+	f, ok := <-s
+	if !ok {
+		return nil, io.EOF
+	}
+	return f, nil
+}
+
+func TestBroadcaster(t *testing.T) {
+	b := NewBroadcaster()
+	src := make(chanSource)
+	done := make(chan error)
+	go func() { done <- b.Run(src) }()
+
+	server := httptest.NewServer(b)
+	defer server.Close()
+	snapshot := httptest.NewServer(b.Snapshot())
+	defer snapshot.Close()
+
+	resp, err := http.Get(snapshot.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got, want := resp.StatusCode, http.StatusServiceUnavailable; got != want {
+		t.Errorf("Snapshot before the first frame responded %v, want %v", got, want)
+	}
+
+	var viewers []*theta.FrameReader
+	for i := 0; i < 2; i++ {
+		resp, err := http.Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		viewers = append(viewers, theta.NewFrameReader(resp.Body))
+	}
+
+	src <- frame
+	for i, v := range viewers {
+		got, err := v.ReadFrame()
+		if err != nil {
+			t.Fatalf("Viewer %d returned error: %v", i, err)
+		}
+		if !bytes.Equal(got, frame) {
+			t.Errorf("Viewer %d got %x, want %x", i, got, frame)
+		}
+	}
+
+	resp, err = http.Get(snapshot.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !bytes.Equal(got, frame) {
+		t.Errorf("Snapshot returned %x, want %x", got, frame)
+	}
+
+	close(src)
+	if err := <-done; err != io.EOF {
+		t.Errorf("Run returned %v, want %v", err, io.EOF)
+	}
+}
+
+func TestBroadcaster_slowViewer(t *testing.T) {
+	b := NewBroadcaster()
+	ch := b.subscribe()
+
+	// Nobody reads ch; publishing must not block and must keep the newest.
+	newest := theta.Frame{0xff, 0xd8, 0x00, 0xff, 0xd9}
+	b.publish(frame)
+	b.publish(frame)
+	b.publish(newest)
+
+	if got := <-ch; !bytes.Equal(got, newest) {
+		t.Errorf("Slow viewer got %x, want %x", got, newest)
+	}
+}
@@ -0,0 +1,251 @@
+// Copyright (c) 2017 "Shun Yokota" All rights reserved
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package projection renders equirectangular images, such as the ones taken by
+// a Theta, in other projections: cubemap faces, rectilinear perspective views
+// and stereographic "little planet" images.
+//
+// Directions are given as yaw and pitch in degrees. A yaw of 0 is the center of
+// the equirectangular image and positive yaws turn right; positive pitches look
+// up. Source pixels are sampled bilinearly, wrapping around horizontally.
+package projection
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"runtime"
+	"sync"
+)
+
+// Face is a face of a cubemap.
+type Face int
+
+// Faces of a cubemap, in the order returned by Cubemap.
+const (
+	Front Face = iota
+	Right
+	Back
+	Left
+	Up
+	Down
+)
+
+var faceNames = [...]string{"front", "right", "back", "left", "up", "down"}
+
+func (f Face) String() string {
+	if f < Front || f > Down {
+		return "unknown"
+	}
+	return faceNames[f]
+}
+
+// faceViews are the directions of the cube faces. The top of the up face is
+// the back of the cube and the top of the down face is its front.
+var faceViews = [...]struct{ yaw, pitch float64 }{
+	Front: {0, 0},
+	Right: {90, 0},
+	Back:  {180, 0},
+	Left:  {-90, 0},
+	Up:    {0, 90},
+	Down:  {0, -90},
+}
+
+// View is a rectilinear perspective view.
+type View struct {
+	Yaw, Pitch, Roll float64 // degrees
+	FOV              float64 // horizontal field of view in degrees, below 180; 90 if 0
+	Width, Height    int
+}
+
+// defaultFOV is the field of view of a View whose FOV is 0.
+const defaultFOV = 90
+
+// Planet is a stereographic "little planet" view, looking down at the nadir
+// from the zenith.
+type Planet struct {
+	Size int     // width and height of the image
+	FOV  float64 // field of view across the image in degrees, below 720; 270 if 0
+	Yaw  float64 // direction at the top of the image in degrees
+}
+
+// CubeFace renders a face of the cubemap of the equirectangular image src as a
+// size x size image.
+func CubeFace(src image.Image, face Face, size int) *image.RGBA {
+	return newSampler(src).perspective(faceView(face, size))
+}
+
+// Cubemap renders the six faces of the cubemap of the equirectangular image
+// src as size x size images, indexed by Face.
+func Cubemap(src image.Image, size int) [6]*image.RGBA {
+	s := newSampler(src)
+	var faces [6]*image.RGBA
+	for f := range faces {
+		faces[f] = s.perspective(faceView(Face(f), size))
+	}
+	return faces
+}
+
+func faceView(face Face, size int) View {
+	v := faceViews[face]
+	return View{Yaw: v.yaw, Pitch: v.pitch, FOV: 90, Width: size, Height: size}
+}
+
+// Perspective renders the view v of the equirectangular image src. An error is
+// returned if the size or the field of view of v is out of range.
+func Perspective(src image.Image, v View) (*image.RGBA, error) {
+	if v.FOV == 0 {
+		v.FOV = defaultFOV
+	}
+	if v.FOV < 0 || v.FOV >= 180 {
+		return nil, fmt.Errorf("projection: field of view %v is not between 0 and 180 degrees", v.FOV)
+	}
+	if v.Width <= 0 || v.Height <= 0 {
+		return nil, fmt.Errorf("projection: invalid view size %vx%v", v.Width, v.Height)
+	}
+	return newSampler(src).perspective(v), nil
+}
+
+// perspective renders the view v, whose FOV must be valid.
+func (s *sampler) perspective(v View) *image.RGBA {
+	f := float64(v.Width) / 2 / math.Tan(radians(v.FOV)/2)
+	sy, cy := math.Sincos(radians(v.Yaw))
+	sp, cp := math.Sincos(radians(v.Pitch))
+	sr, cr := math.Sincos(radians(v.Roll))
+	return s.render(v.Width, v.Height, func(i, j int) (float64, float64, float64) {
+		x := float64(i) + 0.5 - float64(v.Width)/2
+		y := float64(v.Height)/2 - float64(j) - 0.5
+		z := f
+		// Roll around the view axis, then pitch, then yaw.
+		x, y = x*cr-y*sr, x*sr+y*cr
+		y, z = y*cp+z*sp, z*cp-y*sp
+		x, z = x*cy+z*sy, z*cy-x*sy
+		return x, y, z
+	})
+}
+
+// LittlePlanet renders the equirectangular image src as a little planet. An
+// error is returned if the size or the field of view of p is out of range.
+func LittlePlanet(src image.Image, p Planet) (*image.RGBA, error) {
+	fov := p.FOV
+	if fov == 0 {
+		fov = 270
+	}
+	if fov < 0 || fov >= 720 {
+		return nil, fmt.Errorf("projection: field of view %v is not between 0 and 720 degrees", fov)
+	}
+	if p.Size <= 0 {
+		return nil, fmt.Errorf("projection: invalid planet size %v", p.Size)
+	}
+	// The stereographic projection maps an angle t from the nadir to a
+	// radius of 2f tan(t/2).
+	f := float64(p.Size) / 4 / math.Tan(radians(fov)/4)
+	yaw := radians(p.Yaw)
+	img := newSampler(src).render(p.Size, p.Size, func(i, j int) (float64, float64, float64) {
+		u := float64(i) + 0.5 - float64(p.Size)/2
+		v := float64(j) + 0.5 - float64(p.Size)/2
+		t := 2 * math.Atan(math.Hypot(u, v)/(2*f))
+		st, ct := math.Sincos(t)
+		sa, ca := math.Sincos(math.Atan2(u, -v) + yaw)
+		return st * sa, -ct, st * ca
+	})
+	return img, nil
+}
+
+// sampler samples an equirectangular image by direction.
+type sampler struct {
+	img  *image.RGBA
+	w, h int
+}
+
+func newSampler(src image.Image) *sampler {
+	b := src.Bounds()
+	img, ok := src.(*image.RGBA)
+	if !ok || b.Min != (image.Point{}) {
+		img = image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+		draw.Draw(img, img.Bounds(), src, b.Min, draw.Src)
+	}
+	return &sampler{img: img, w: b.Dx(), h: b.Dy()}
+}
+
+// render returns a w x h image whose pixel (i, j) shows the direction returned
+// by dir. Rows are rendered in parallel.
+func (s *sampler) render(w, h int, dir func(i, j int) (x, y, z float64)) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	rows := make(chan int)
+	var wg sync.WaitGroup
+	for n := 0; n < runtime.NumCPU(); n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range rows {
+				for i := 0; i < w; i++ {
+					x, y, z := dir(i, j)
+					lon := math.Atan2(x, z)
+					lat := math.Atan2(y, math.Hypot(x, z))
+					dst.SetRGBA(i, j, s.at(lon, lat))
+				}
+			}
+		}()
+	}
+	for j := 0; j < h; j++ {
+		rows <- j
+	}
+	close(rows)
+	wg.Wait()
+	return dst
+}
+
+// at returns the color at longitude lon and latitude lat in radians.
+func (s *sampler) at(lon, lat float64) color.RGBA {
+	x := (lon/(2*math.Pi)+0.5)*float64(s.w) - 0.5
+	y := (0.5-lat/math.Pi)*float64(s.h) - 0.5
+	return s.bilinear(x, y)
+}
+
+// bilinear interpolates the pixels around (x, y), wrapping around horizontally
+// and clamping vertically.
+func (s *sampler) bilinear(x, y float64) color.RGBA {
+	x0, y0 := math.Floor(x), math.Floor(y)
+	fx, fy := x-x0, y-y0
+	xs := [2]int{wrap(int(x0), s.w), wrap(int(x0)+1, s.w)}
+	ys := [2]int{clamp(int(y0), s.h), clamp(int(y0)+1, s.h)}
+	wx := [2]float64{1 - fx, fx}
+	wy := [2]float64{1 - fy, fy}
+
+	var c [4]float64
+	for a, py := range ys {
+		for b, px := range xs {
+			wt := wy[a] * wx[b]
+			p := s.img.Pix[py*s.img.Stride+4*px:]
+			for k := range c {
+				c[k] += wt * float64(p[k])
+			}
+		}
+	}
+	return color.RGBA{uint8(c[0] + 0.5), uint8(c[1] + 0.5), uint8(c[2] + 0.5), uint8(c[3] + 0.5)}
+}
+
+func radians(deg float64) float64 { return deg * math.Pi / 180 }
+
+func wrap(x, n int) int {
+	x %= n
+	if x < 0 {
+		x += n
+	}
+	return x
+}
+
+func clamp(x, n int) int {
+	switch {
+	case x < 0:
+		return 0
+	case x >= n:
+		return n - 1
+	}
+	return x
+}
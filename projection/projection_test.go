@@ -0,0 +1,133 @@
+// Copyright (c) 2017 "Shun Yokota" All rights reserved
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package projection
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// pano returns an equirectangular image whose red channel encodes the
+// longitude and whose green channel encodes the latitude of each pixel.
+func pano(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetRGBA(x, y, color.RGBA{uint8(x * 256 / w), uint8(y * 256 / h), 0, 255})
+		}
+	}
+	return img
+}
+
+func near(a, b uint8, d int) bool {
+	return int(a)-int(b) <= d && int(b)-int(a) <= d
+}
+
+func TestCubemap(t *testing.T) {
+	src := pano(256, 128)
+	faces := Cubemap(src, 32)
+	tests := []struct {
+		face Face
+		want color.RGBA // source pixel at the center of the face
+	}{
+		{Front, src.RGBAAt(128, 64)},
+		{Right, src.RGBAAt(192, 64)},
+		{Back, src.RGBAAt(0, 64)},
+		{Left, src.RGBAAt(64, 64)},
+	}
+	for _, tt := range tests {
+		got := faces[tt.face].RGBAAt(16, 16)
+		if !near(got.R, tt.want.R, 2) || !near(got.G, tt.want.G, 2) {
+			t.Errorf("%v face center is %v, want %v", tt.face, got, tt.want)
+		}
+	}
+	if got := faces[Up].RGBAAt(16, 16).G; got > 6 {
+		t.Errorf("up face center has latitude %v, want 0", got)
+	}
+	if got := faces[Down].RGBAAt(16, 16).G; got < 249 {
+		t.Errorf("down face center has latitude %v, want 255", got)
+	}
+	// The top of the up face is the back of the cube.
+	if got, want := faces[Up].RGBAAt(16, 0).R, src.RGBAAt(0, 0).R; !near(got, want, 2) && !near(got, 255, 2) {
+		t.Errorf("top of up face has longitude %v, want %v", got, want)
+	}
+
+	if got, want := CubeFace(src, Left, 32).RGBAAt(3, 5), faces[Left].RGBAAt(3, 5); got != want {
+		t.Errorf("CubeFace returned %v, want %v", got, want)
+	}
+}
+
+func TestPerspective(t *testing.T) {
+	src := pano(360, 180)
+	v := View{Yaw: 45, Pitch: 30, Roll: 90, FOV: 60, Width: 40, Height: 20}
+	img, err := Perspective(src, v)
+	if err != nil {
+		t.Fatalf("Perspective returned error: %v", err)
+	}
+	got := img.RGBAAt(20, 10)
+	want := src.RGBAAt(225, 60)
+	if !near(got.R, want.R, 2) || !near(got.G, want.G, 2) {
+		t.Errorf("Perspective center is %v, want %v", got, want)
+	}
+
+	// Rolling by 90 degrees turns the right of the view into its bottom.
+	rolled, _ := Perspective(src, View{Roll: 90, FOV: 90, Width: 20, Height: 20})
+	level, _ := Perspective(src, View{Width: 20, Height: 20}) // default FOV of 90
+	if got, want := rolled.RGBAAt(10, 19).R, level.RGBAAt(19, 10).R; !near(got, want, 2) {
+		t.Errorf("rolled view bottom has longitude %v, want %v", got, want)
+	}
+}
+
+func TestPerspective_invalid(t *testing.T) {
+	src := pano(36, 18)
+	for _, v := range []View{
+		{FOV: 180, Width: 10, Height: 10},
+		{FOV: -30, Width: 10, Height: 10},
+		{FOV: 90},
+	} {
+		if _, err := Perspective(src, v); err == nil {
+			t.Errorf("Perspective(%+v) returned no error", v)
+		}
+	}
+	if _, err := LittlePlanet(src, Planet{Size: 10, FOV: 720}); err == nil {
+		t.Errorf("LittlePlanet with FOV 720 returned no error")
+	}
+}
+
+func TestLittlePlanet(t *testing.T) {
+	src := pano(360, 180)
+	img, err := LittlePlanet(src, Planet{Size: 64})
+	if err != nil {
+		t.Fatalf("LittlePlanet returned error: %v", err)
+	}
+	if got := img.RGBAAt(32, 32).G; got < 240 {
+		t.Errorf("LittlePlanet center has latitude %v, want the nadir", got)
+	}
+	// The front is at the top, looking at the horizon at a quarter of the
+	// field of view.
+	top := img.RGBAAt(32, 0)
+	if !near(top.R, 128, 3) {
+		t.Errorf("LittlePlanet top has longitude %v, want 128", top.R)
+	}
+	if top.G > 64 {
+		t.Errorf("LittlePlanet top has latitude %v, want above the horizon", top.G)
+	}
+}
+
+func TestSampler_wrap(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	src.SetRGBA(0, 0, color.RGBA{200, 0, 0, 255})
+	src.SetRGBA(3, 0, color.RGBA{100, 0, 0, 255})
+	s := newSampler(src)
+	// Halfway between the last and the first column.
+	if got, want := s.bilinear(3.5, 0), (color.RGBA{150, 0, 0, 255}); got != want {
+		t.Errorf("bilinear returned %v, want %v", got, want)
+	}
+	if got, want := s.bilinear(-0.5, -3), (color.RGBA{150, 0, 0, 255}); got != want {
+		t.Errorf("bilinear returned %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,294 @@
+// Copyright (c) 2017 "Shun Yokota" All rights reserved
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package stitch converts dual-fisheye images, which a Theta takes when
+// _imageStitching is "none" or records as _dualFisheye video, to
+// equirectangular images.
+//
+// Each lens is modeled as an equidistant fisheye. A camera lays its image
+// circles out differently in still images and in video frames, so a Model holds
+// a Layout per frame size. The presets are nominal values derived from the frame
+// sizes of each camera rather than calibrated against its frames; individual
+// cameras differ slightly, so a Layout may be refined for a cleaner seam.
+package stitch
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Lens describes where a lens projects its image circle in a dual-fisheye frame.
+type Lens struct {
+	CenterX, CenterY float64 // center of the image circle, relative to the frame width and height
+	Radius           float64 // radius of the image circle, relative to the frame height
+	FOV              float64 // field of view covered by the image circle in degrees
+	Yaw              float64 // direction the lens faces in degrees, 0 for the front
+	Rotation         float64 // counterclockwise rotation of the image circle in degrees
+}
+
+// Layout is the placement of the image circles in frames of a size. It is also
+// used for frames of the same aspect ratio.
+type Layout struct {
+	Width, Height int
+	Lenses        [2]Lens
+}
+
+// Model is the lens model of a camera.
+type Model struct {
+	Name    string
+	Layouts []Layout
+}
+
+// Layout returns the layout of the model for w x h frames.
+func (m Model) Layout(w, h int) (Layout, bool) {
+	for _, l := range m.Layouts {
+		// Compare the aspect ratios, allowing for rounding of the size.
+		if math.Abs(float64(l.Width*h)/float64(l.Height*w)-1) < 0.01 {
+			return l, true
+		}
+	}
+	return Layout{}, false
+}
+
+// sideBySide returns a layout whose image circles are side by side, the front
+// lens on the left. The circles are turned by rotation degrees, the back one
+// the other way.
+func sideBySide(w, h int, radius, fov, rotation float64) Layout {
+	return Layout{
+		Width:  w,
+		Height: h,
+		Lenses: [2]Lens{
+			{CenterX: 0.25, CenterY: 0.5, Radius: radius, FOV: fov, Yaw: 0, Rotation: rotation},
+			{CenterX: 0.75, CenterY: 0.5, Radius: radius, FOV: fov, Yaw: 180, Rotation: -rotation},
+		},
+	}
+}
+
+// Lens models of the Theta cameras. Still images are 2:1 with upright image
+// circles, and so are 2:1 video frames. The _dualFisheye video of the S and SC
+// is 16:9 with the circles turned by 90 degrees, each filling the width of its
+// half of the frame. The SC shares the lenses and sensor of the S, so it is
+// modeled the same.
+var (
+	ThetaS = Model{
+		Name: "RICOH THETA S",
+		Layouts: []Layout{
+			sideBySide(5376, 2688, 0.472, 190, 0),
+			sideBySide(1920, 1080, 0.441, 190, 90),
+		},
+	}
+	ThetaSC = Model{
+		Name: "RICOH THETA SC",
+		Layouts: []Layout{
+			sideBySide(5376, 2688, 0.472, 190, 0),
+			sideBySide(1920, 1080, 0.441, 190, 90),
+		},
+	}
+	ThetaV = Model{
+		Name: "RICOH THETA V",
+		Layouts: []Layout{
+			sideBySide(5376, 2688, 0.476, 190, 0),
+		},
+	}
+	ThetaZ1 = Model{
+		Name: "RICOH THETA Z1",
+		Layouts: []Layout{
+			sideBySide(7296, 3648, 0.484, 193, 0),
+		},
+	}
+)
+
+var models = []Model{ThetaS, ThetaSC, ThetaV, ThetaZ1}
+
+// ModelFor returns the lens model of the camera named model, as reported in
+// theta.Info.Model.
+func ModelFor(model string) (Model, bool) {
+	for _, m := range models {
+		if strings.EqualFold(m.Name, model) {
+			return m, true
+		}
+	}
+	return Model{}, false
+}
+
+// Stitcher converts dual-fisheye frames to equirectangular images.
+type Stitcher struct {
+	Model Model
+
+	// Width is the width of the equirectangular images, whose height is half
+	// of it. If 0, it is the width of the frame.
+	Width int
+
+	// Blend is how far from the seam, in degrees, the images of the two
+	// lenses are cross-faded. If 0, the whole overlap of the lenses is used.
+	Blend float64
+}
+
+// Stitch converts the dual-fisheye frame src to an equirectangular image, using
+// the layout of the model for the size of src.
+func (s *Stitcher) Stitch(src image.Image) (*image.RGBA, error) {
+	b := src.Bounds()
+	if b.Empty() {
+		return nil, errors.New("stitch: empty frame")
+	}
+	layout, ok := s.Model.Layout(b.Dx(), b.Dy())
+	if !ok {
+		return nil, fmt.Errorf("stitch: %v has no layout for %dx%d frames", s.Model.Name, b.Dx(), b.Dy())
+	}
+	frame, ok := src.(*image.RGBA)
+	if !ok || b.Min != (image.Point{}) {
+		frame = image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+		draw.Draw(frame, frame.Bounds(), src, b.Min, draw.Src)
+	}
+
+	w := s.Width
+	if w == 0 {
+		w = b.Dx()
+	}
+	h := w / 2
+	lenses := make([]lens, len(layout.Lenses))
+	for i, l := range layout.Lenses {
+		lenses[i] = newLens(l, b.Dx(), b.Dy())
+	}
+	blend := s.Blend
+	if blend == 0 {
+		blend = (math.Min(layout.Lenses[0].FOV, layout.Lenses[1].FOV) - 180) / 2
+	}
+	blend = math.Max(radians(blend), 1e-6)
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	rows := make(chan int)
+	var wg sync.WaitGroup
+	for n := 0; n < runtime.NumCPU(); n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range rows {
+				lat := (0.5 - (float64(j)+0.5)/float64(h)) * math.Pi
+				for i := 0; i < w; i++ {
+					lon := ((float64(i)+0.5)/float64(w) - 0.5) * 2 * math.Pi
+					dst.SetRGBA(i, j, blendLenses(frame, lenses, lon, lat, blend))
+				}
+			}
+		}()
+	}
+	for j := 0; j < h; j++ {
+		rows <- j
+	}
+	close(rows)
+	wg.Wait()
+	return dst, nil
+}
+
+// lens is a Lens in pixels and radians.
+type lens struct {
+	cx, cy     float64
+	r          float64 // pixels per radian from the optical axis
+	halfFOV    float64
+	sinY, cosY float64
+	rot        float64
+}
+
+func newLens(l Lens, w, h int) lens {
+	halfFOV := radians(l.FOV) / 2
+	sy, cy := math.Sincos(radians(l.Yaw))
+	return lens{
+		cx:      l.CenterX*float64(w) - 0.5,
+		cy:      l.CenterY*float64(h) - 0.5,
+		r:       l.Radius * float64(h) / halfFOV,
+		halfFOV: halfFOV,
+		sinY:    sy,
+		cosY:    cy,
+		rot:     radians(l.Rotation),
+	}
+}
+
+// project returns the angle of the direction (x, y, z) from the optical axis of
+// l and the point where l images it.
+func (l *lens) project(x, y, z float64) (theta, u, v float64) {
+	// Turn the direction into the frame of the lens, looking along z.
+	x, z = x*l.cosY-z*l.sinY, x*l.sinY+z*l.cosY
+	theta = math.Acos(math.Max(-1, math.Min(1, z)))
+	phi := math.Atan2(y, x) + l.rot
+	sp, cp := math.Sincos(phi)
+	r := l.r * theta
+	return theta, l.cx + r*cp, l.cy - r*sp
+}
+
+// blendLenses returns the color at longitude lon and latitude lat, cross-fading
+// the two lenses over blend radians around the seam.
+func blendLenses(frame *image.RGBA, lenses []lens, lon, lat, blend float64) color.RGBA {
+	sLat, cLat := math.Sincos(lat)
+	sLon, cLon := math.Sincos(lon)
+	x, y, z := cLat*sLon, sLat, cLat*cLon
+
+	var thetas, us, vs [2]float64
+	for i := range lenses {
+		thetas[i], us[i], vs[i] = lenses[i].project(x, y, z)
+	}
+	// The weight of the first lens goes from 1 to 0 as the direction crosses
+	// the seam, halfway between the optical axes.
+	wt := math.Max(0, math.Min(1, 0.5+(thetas[1]-thetas[0])/(4*blend)))
+	if thetas[0] > lenses[0].halfFOV {
+		wt = 0
+	}
+	if thetas[1] > lenses[1].halfFOV {
+		wt = 1
+	}
+
+	var c [4]float64
+	for i, f := range [2]float64{wt, 1 - wt} {
+		if f == 0 {
+			continue
+		}
+		p := bilinear(frame, us[i], vs[i])
+		for k := range c {
+			c[k] += f * p[k]
+		}
+	}
+	return color.RGBA{uint8(c[0] + 0.5), uint8(c[1] + 0.5), uint8(c[2] + 0.5), uint8(c[3] + 0.5)}
+}
+
+// bilinear interpolates the pixels of img around (x, y), clamping at the edges.
+func bilinear(img *image.RGBA, x, y float64) [4]float64 {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	x = math.Max(0, math.Min(float64(w-1), x))
+	y = math.Max(0, math.Min(float64(h-1), y))
+	x0, y0 := int(x), int(y)
+	x1, y1 := x0+1, y0+1
+	if x1 >= w {
+		x1 = w - 1
+	}
+	if y1 >= h {
+		y1 = h - 1
+	}
+	fx, fy := x-float64(x0), y-float64(y0)
+
+	var c [4]float64
+	for _, s := range []struct {
+		x, y int
+		w    float64
+	}{
+		{x0, y0, (1 - fx) * (1 - fy)},
+		{x1, y0, fx * (1 - fy)},
+		{x0, y1, (1 - fx) * fy},
+		{x1, y1, fx * fy},
+	} {
+		p := img.Pix[s.y*img.Stride+4*s.x:]
+		for k := range c {
+			c[k] += s.w * float64(p[k])
+		}
+	}
+	return c
+}
+
+func radians(deg float64) float64 { return deg * math.Pi / 180 }
@@ -0,0 +1,207 @@
+// Copyright (c) 2017 "Shun Yokota" All rights reserved
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stitch
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+var (
+	red  = color.RGBA{255, 0, 0, 255}
+	blue = color.RGBA{0, 0, 255, 255}
+)
+
+// dualFisheye returns a frame whose front lens sees red and back lens sees blue.
+func dualFisheye(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if x < w/2 {
+				img.SetRGBA(x, y, red)
+			} else {
+				img.SetRGBA(x, y, blue)
+			}
+		}
+	}
+	return img
+}
+
+func TestStitcher_Stitch(t *testing.T) {
+	s := &Stitcher{Model: ThetaS, Width: 360}
+	img, err := s.Stitch(dualFisheye(400, 200))
+	if err != nil {
+		t.Fatalf("Stitch returned error: %v", err)
+	}
+	if got, want := img.Bounds(), image.Rect(0, 0, 360, 180); got != want {
+		t.Fatalf("Stitch returned bounds %v, want %v", got, want)
+	}
+
+	tests := []struct {
+		x, y int
+		want color.RGBA
+	}{
+		{180, 90, red},  // front
+		{0, 90, blue},   // back
+		{359, 90, blue}, // back
+		{170, 5, red},   // near the zenith, front side
+		{100, 90, red},  // 80 degrees left of the front
+		{80, 90, blue},  // 100 degrees left of the front
+	}
+	for _, tt := range tests {
+		if got := img.RGBAAt(tt.x, tt.y); got != tt.want {
+			t.Errorf("Stitch pixel at (%d, %d) is %v, want %v", tt.x, tt.y, got, tt.want)
+		}
+	}
+
+	// The lenses are cross-faded at the seam, 90 degrees right of the front.
+	if got := img.RGBAAt(270, 90); got.R < 100 || got.B < 100 {
+		t.Errorf("Stitch pixel at the seam is %v, want a blend of red and blue", got)
+	}
+}
+
+func TestStitcher_Stitch_defaultWidth(t *testing.T) {
+	s := &Stitcher{Model: ThetaZ1}
+	img, err := s.Stitch(dualFisheye(64, 32))
+	if err != nil {
+		t.Fatalf("Stitch returned error: %v", err)
+	}
+	if got, want := img.Bounds(), image.Rect(0, 0, 64, 32); got != want {
+		t.Errorf("Stitch returned bounds %v, want %v", got, want)
+	}
+	if _, err := s.Stitch(image.NewRGBA(image.Rectangle{})); err == nil {
+		t.Errorf("Stitch with an empty frame returned no error")
+	}
+}
+
+// scene returns the color of a smooth test scene in the direction lon, lat.
+func scene(lon, lat float64) color.RGBA {
+	return color.RGBA{
+		uint8(128 + 100*math.Sin(lon)*math.Cos(lat)),
+		uint8(128 + 100*math.Sin(lat)),
+		uint8(128 + 100*math.Cos(lon)*math.Cos(lat)),
+		255,
+	}
+}
+
+// shoot renders the scene as a w x h dual-fisheye frame of layout, inverting
+// the lens model independently of Stitch.
+func shoot(layout Layout, w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for _, l := range layout.Lenses {
+		cx, cy := l.CenterX*float64(w), l.CenterY*float64(h)
+		radius := l.Radius * float64(h)
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				dx, dy := float64(x)+0.5-cx, cy-float64(y)-0.5
+				r := math.Hypot(dx, dy)
+				if r > radius {
+					continue
+				}
+				theta := r / radius * radians(l.FOV) / 2
+				phi := math.Atan2(dy, dx) - radians(l.Rotation)
+				// The direction in the frame of the lens, then in the world.
+				lx, ly, lz := math.Sin(theta)*math.Cos(phi), math.Sin(theta)*math.Sin(phi), math.Cos(theta)
+				sy, cy := math.Sincos(radians(l.Yaw))
+				wx, wz := lx*cy+lz*sy, lz*cy-lx*sy
+				img.SetRGBA(x, y, scene(math.Atan2(wx, wz), math.Asin(ly)))
+			}
+		}
+	}
+	return img
+}
+
+func TestStitcher_Stitch_roundTrip(t *testing.T) {
+	tests := []struct {
+		model Model
+		w, h  int
+	}{
+		{ThetaS, 384, 216}, // _dualFisheye video, turned circles
+		{ThetaS, 384, 192}, // still image
+		{ThetaZ1, 384, 192},
+	}
+	for _, tt := range tests {
+		layout, ok := tt.model.Layout(tt.w, tt.h)
+		if !ok {
+			t.Fatalf("%v has no layout for %dx%d", tt.model.Name, tt.w, tt.h)
+		}
+		frame := shoot(layout, tt.w, tt.h)
+
+		if got := meanError(t, &Stitcher{Model: tt.model, Width: 128}, frame); got > 1 {
+			t.Errorf("%v %dx%d: stitched image differs from the scene by %.1f on average", tt.model.Name, tt.w, tt.h, got)
+		}
+
+		// A layout with the wrong rotation must not reproduce the scene, so
+		// the test is sensitive to the rotation of the circles.
+		wrong := layout
+		for i := range wrong.Lenses {
+			wrong.Lenses[i].Rotation += 90
+		}
+		m := Model{Name: "wrong", Layouts: []Layout{wrong}}
+		if got := meanError(t, &Stitcher{Model: m, Width: 128}, frame); got < 10 {
+			t.Errorf("%v %dx%d: wrongly rotated layout differs by only %.1f", tt.model.Name, tt.w, tt.h, got)
+		}
+	}
+}
+
+// meanError stitches frame and returns the mean absolute difference from the
+// scene per channel.
+func meanError(t *testing.T, s *Stitcher, frame image.Image) float64 {
+	img, err := s.Stitch(frame)
+	if err != nil {
+		t.Fatalf("Stitch returned error: %v", err)
+	}
+	b := img.Bounds()
+	var sum float64
+	for y := 0; y < b.Dy(); y++ {
+		lat := (0.5 - (float64(y)+0.5)/float64(b.Dy())) * math.Pi
+		for x := 0; x < b.Dx(); x++ {
+			lon := ((float64(x)+0.5)/float64(b.Dx()) - 0.5) * 2 * math.Pi
+			got, want := img.RGBAAt(x, y), scene(lon, lat)
+			sum += math.Abs(float64(got.R)-float64(want.R)) +
+				math.Abs(float64(got.G)-float64(want.G)) +
+				math.Abs(float64(got.B)-float64(want.B))
+		}
+	}
+	return sum / float64(3*b.Dx()*b.Dy())
+}
+
+func TestModel_Layout(t *testing.T) {
+	if _, ok := ThetaS.Layout(1920, 1080); !ok {
+		t.Errorf("ThetaS has no layout for 1920x1080 video")
+	}
+	if l, ok := ThetaV.Layout(3840, 1920); !ok || l.Width != 5376 {
+		t.Errorf("ThetaV.Layout(3840, 1920) returned %+v, %v, want the 2:1 layout", l, ok)
+	}
+	if _, err := (&Stitcher{Model: ThetaZ1}).Stitch(dualFisheye(160, 90)); err == nil {
+		t.Errorf("Stitch of a 16:9 frame with ThetaZ1 returned no error")
+	}
+}
+
+func TestLens_project(t *testing.T) {
+	l := newLens(Lens{CenterX: 0.25, CenterY: 0.5, Radius: 0.5, FOV: 180, Yaw: 0, Rotation: 90}, 200, 100)
+	// A direction 90 degrees right of the axis falls on the edge of the image
+	// circle, rotated to its top.
+	theta, u, v := l.project(1, 0, 0)
+	if math.Abs(theta-math.Pi/2) > 1e-9 {
+		t.Errorf("project returned theta %v, want pi/2", theta)
+	}
+	if u < 49 || u > 50 || v < -0.6 || v > -0.4 {
+		t.Errorf("project returned (%v, %v), want (49.5, -0.5)", u, v)
+	}
+}
+
+func TestModelFor(t *testing.T) {
+	m, ok := ModelFor("RICOH THETA Z1")
+	if !ok || m.Name != ThetaZ1.Name {
+		t.Errorf("ModelFor returned %v, %v, want %v", m.Name, ok, ThetaZ1.Name)
+	}
+	if _, ok := ModelFor("RICOH THETA m15"); ok {
+		t.Errorf("ModelFor returned a model for an unknown camera")
+	}
+}
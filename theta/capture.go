@@ -0,0 +1,149 @@
+// Copyright (c) 2017 "Shun Yokota" All rights reserved
+//
+// Part of the source code is adapted from https://github.com/google/go-github
+// Copyright 2013 The go-github AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package theta
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Kinds of capture started by StartCapture.
+const (
+	CaptureVideo     = "video"
+	CaptureInterval  = "interval"
+	CaptureComposite = "composite"
+)
+
+// Values of Options.CaptureMode.
+const (
+	CaptureModeImage    = "image"
+	CaptureModeVideo    = "video"
+	CaptureModeVideov20 = "_video" // THETA S and SC, and Theta API v2.0
+)
+
+// TakePicture takes a still image and waits until the image is saved. The URL of
+// the image file is returned: the fileUrl in Theta API v2.1, or the fileUri in
+// Theta API v2.0.
+func (s *CommandServices) TakePicture(ctx context.Context) (string, *http.Response, error) {
+	body := CommandRequest{Name: String("camera.takePicture")}
+	if id := s.client.session(); id != nil {
+		body.Parameters = &Parameters{SessionID: id}
+	}
+	cmd, resp, err := s.commandsExecute(ctx, body)
+	if err != nil {
+		return "", resp, err
+	}
+	cmd, r, err := s.Wait(ctx, cmd)
+	if r != nil {
+		resp = r
+	}
+	if err != nil {
+		return "", resp, err
+	}
+	if cmd.Results != nil {
+		if cmd.Results.FileURL != nil {
+			return *cmd.Results.FileURL, resp, nil
+		}
+		if cmd.Results.FileURI != nil {
+			return *cmd.Results.FileURI, resp, nil
+		}
+	}
+	return "", resp, errors.New("takePicture finished without a file")
+}
+
+// StartCapture starts a capture of the kind, such as CaptureVideo. The capture
+// mode of the camera is switched to match it first: "video" or "_video",
+// whichever the camera supports, for a video, and "image" otherwise. The
+// capture continues until StopCapture is called, or until the number of shots
+// set with captureNumber is taken.
+func (s *CommandServices) StartCapture(ctx context.Context, kind string) (*http.Response, error) {
+	mode := CaptureModeImage
+	if kind == CaptureVideo {
+		support, resp, err := s.GetOptions(ctx, OptionCaptureModeSupport)
+		if err != nil {
+			return resp, err
+		}
+		mode = CaptureModeVideov20
+		if containsString(support.CaptureModeSupport, CaptureModeVideo) {
+			mode = CaptureModeVideo
+		}
+	}
+	if _, resp, err := s.SetOptions(ctx, &Options{CaptureMode: String(mode)}); err != nil {
+		return resp, err
+	}
+
+	body := CommandRequest{Name: String("camera.startCapture")}
+	if id := s.client.session(); id != nil {
+		body.Parameters = &Parameters{SessionID: id}
+		switch kind {
+		case CaptureVideo:
+			body.Name = String("camera._startRecording")
+		case CaptureInterval:
+			body.Name = String("camera._startCapture")
+		default:
+			return nil, fmt.Errorf("capture %q is not supported in Theta API v2.0", kind)
+		}
+	} else if kind == CaptureComposite {
+		body.Parameters = &Parameters{Mode: String(kind)}
+	}
+	_, resp, err := s.commandsExecute(ctx, body)
+	if err != nil {
+		return resp, err
+	}
+	s.client.mu.Lock()
+	s.client.capture = kind
+	s.client.mu.Unlock()
+	return resp, nil
+}
+
+// StopCapture stops the capture started by StartCapture and returns the URLs of
+// the resulting files, if the camera reports them.
+func (s *CommandServices) StopCapture(ctx context.Context) ([]string, *http.Response, error) {
+	s.client.mu.Lock()
+	kind := s.client.capture
+	s.client.mu.Unlock()
+
+	body := CommandRequest{Name: String("camera.stopCapture")}
+	if id := s.client.session(); id != nil {
+		body.Parameters = &Parameters{SessionID: id}
+		body.Name = String("camera._stopCapture")
+		if kind == CaptureVideo {
+			body.Name = String("camera._stopRecording")
+		}
+	}
+	cmd, resp, err := s.commandsExecute(ctx, body)
+	if err != nil {
+		return nil, resp, err
+	}
+	cmd, r, err := s.Wait(ctx, cmd)
+	if r != nil {
+		resp = r
+	}
+	if err != nil {
+		return nil, resp, err
+	}
+	s.client.mu.Lock()
+	s.client.capture = ""
+	s.client.mu.Unlock()
+
+	var urls []string
+	if r := cmd.Results; r != nil {
+		switch {
+		case len(r.FileURLs) > 0:
+			urls = r.FileURLs
+		case r.FileURL != nil:
+			urls = []string{*r.FileURL}
+		case r.FileURI != nil:
+			urls = []string{*r.FileURI}
+		}
+	}
+	return urls, resp, nil
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2017 "Shun Yokota" All rights reserved
+//
+// Part of the source code is adapted from https://github.com/google/go-github
+// Copyright 2013 The go-github AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package theta
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestCommandServices_StartCapture_video(t *testing.T) {
+	setup()
+	defer teardown()
+	client.apiLevel = 2
+
+	var names []string
+	mux.HandleFunc(commandsExecuteURL, func(w http.ResponseWriter, r *http.Request) {
+		v := new(CommandRequest)
+		json.NewDecoder(r.Body).Decode(v)
+		names = append(names, *v.Name)
+		switch *v.Name {
+		case "camera.getOptions":
+			fmt.Fprint(w, `{"name":"camera.getOptions","state":"done","results":{"options":{"captureModeSupport":["image","_video"]}}}`)
+		case "camera.setOptions":
+			if got, want := *v.Parameters.Options.CaptureMode, CaptureModeVideov20; got != want {
+				t.Errorf("setOptions captureMode is %v, want %v", got, want)
+			}
+			fmt.Fprint(w, `{"name":"camera.setOptions","state":"done"}`)
+		case "camera.startCapture":
+			fmt.Fprint(w, `{"name":"camera.startCapture","state":"done"}`)
+		case "camera.stopCapture":
+			fmt.Fprint(w, `{"name":"camera.stopCapture","state":"done","results":{"fileUrls":["http://192.168.1.1/files/R0010001.MP4"]}}`)
+		}
+	})
+
+	if _, err := client.Command.StartCapture(context.Background(), CaptureVideo); err != nil {
+		t.Fatalf("Command.StartCapture returned error: %v", err)
+	}
+	urls, _, err := client.Command.StopCapture(context.Background())
+	if err != nil {
+		t.Fatalf("Command.StopCapture returned error: %v", err)
+	}
+	if want := []string{"http://192.168.1.1/files/R0010001.MP4"}; !reflect.DeepEqual(urls, want) {
+		t.Errorf("Command.StopCapture returned %v, want %v", urls, want)
+	}
+	want := []string{"camera.getOptions", "camera.setOptions", "camera.startCapture", "camera.stopCapture"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("Commands sent are %v, want %v", names, want)
+	}
+}
+
+func TestCommandServices_StartCapture_v20(t *testing.T) {
+	setup()
+	defer teardown()
+	client.sessionID = "SID_0001"
+
+	var names []string
+	mux.HandleFunc(commandsExecuteURL, func(w http.ResponseWriter, r *http.Request) {
+		v := new(CommandRequest)
+		json.NewDecoder(r.Body).Decode(v)
+		names = append(names, *v.Name)
+		if *v.Name == "camera.getOptions" {
+			fmt.Fprint(w, `{"name":"camera.getOptions","state":"done","results":{"options":{"captureModeSupport":["image","_video"]}}}`)
+			return
+		}
+		fmt.Fprintf(w, `{"name":%q,"state":"done"}`, *v.Name)
+	})
+
+	if _, err := client.Command.StartCapture(context.Background(), CaptureVideo); err != nil {
+		t.Fatalf("Command.StartCapture returned error: %v", err)
+	}
+	if _, _, err := client.Command.StopCapture(context.Background()); err != nil {
+		t.Fatalf("Command.StopCapture returned error: %v", err)
+	}
+	want := []string{"camera.getOptions", "camera.setOptions", "camera._startRecording", "camera._stopRecording"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("Commands sent are %v, want %v", names, want)
+	}
+}
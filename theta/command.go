@@ -10,14 +10,25 @@ package theta
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"net/http"
+	"time"
+)
+
+// States of a command reported in CommandResponse.State.
+const (
+	CommandStateDone       = "done"
+	CommandStateInProgress = "inProgress"
+	CommandStateError      = "error"
 )
 
 // CommandRequest represents a Commands request from Theta API.
 type CommandRequest struct {
 	Name       *string     `json:"name,omitempty"`
 	Parameters *Parameters `json:"parameters,omitempty"`
+
+	// ID is used by commands/status to identify an in-progress command.
+	ID *string `json:"id,omitempty"`
 }
 
 func (c CommandRequest) String() string {
@@ -26,10 +37,31 @@ func (c CommandRequest) String() string {
 
 // Parameters represents a command request parameters.
 type Parameters struct {
-	Options *Options `json:"options,omitempty"`
+	Options     *Options `json:"options,omitempty"`
+	OptionNames []string `json:"optionNames,omitempty"`
+
+	// camera.listFiles
+	FileType      *string `json:"fileType,omitempty"`
+	StartPosition *int    `json:"startPosition,omitempty"`
+	EntryCount    *int    `json:"entryCount,omitempty"`
+	MaxThumbSize  *int    `json:"maxThumbSize,omitempty"`
+	Detail        *bool   `json:"_detail,omitempty"`
+
+	// camera.delete
+	FileURLs []string `json:"fileUrls,omitempty"`
+
+	// camera.getMetadata
+	FileURL *string `json:"fileUrl,omitempty"`
+
+	// camera.startCapture
+	Mode *string `json:"_mode,omitempty"`
 
 	// Deprecated in Theta API v2.1 (OSC v2.0).
-	SessionID *string `json:"sessionId,omitempty"`
+	SessionID         *string `json:"sessionId,omitempty"`
+	ContinuationToken *string `json:"continuationToken,omitempty"`
+	MaxSize           *int    `json:"maxSize,omitempty"`
+	IncludeThumb      *bool   `json:"includeThumb,omitempty"`
+	FileURI           *string `json:"fileUri,omitempty"`
 }
 
 func (p Parameters) String() string {
@@ -54,56 +86,94 @@ func (c CommandResponse) String() string {
 type Results struct {
 	Timeout *int `json:"timeout"`
 
-	FileURI *string `json:"fileUri"`
+	FileURL  *string  `json:"fileUrl"`
+	FileURLs []string `json:"fileUrls"`
 
-	Entries      *Entries `json:"entries"`
-	TotalEntries *int     `json:"totalEntries"`
+	Entries      []*Entries `json:"entries"`
+	TotalEntries *int       `json:"totalEntries"`
 
 	EXIF *EXIF `json:"exif"`
-	XMP  *XMP  `json:"XMP"`
+	XMP  *XMP  `json:"xmp"`
 
 	Options *Options `json:"options"`
 
 	// Deprecated in Theta API v2.1 (OSC v2.0).
 	SessionID         *string `json:"sessionId"`
 	ContinuationToken *string `json:"continuationToken"`
+	FileURI           *string `json:"fileUri"`
 }
 
 func (r Results) String() string {
 	return Stringify(r)
 }
 
-// Entries represents file list of acquired still image files.
+// Entries represents an entry of the file list returned by listFiles and
+// listImages. Thumbnail holds the decoded JPEG bytes of the thumbnail.
 type Entries struct {
-	Name                     *string `json:"name"`
-	FileURL                  *string `json:"fileUrl"`
-	Size                     *int    `json:"size"`
-	DateTimeZone             *string `json:"dataTimeZone"`
-	DateTime                 *string `json:"dateTime"`
-	Width                    *int    `json:"width"`
-	Height                   *int    `json:"height"`
-	RecordTime               *int    `json:"_recordTime"`
-	Thumbnail                *string `json:"_thumbnail"`
-	ThumbSize                *string `json:"_thmubSize"`
-	IntervalCaptureGroupID   *string `json:"_intervalCaptureGroupId"`
-	CompositeShootingGroupID *string `json:"_compositeShootingGroupId"`
-	AutoBracketGroupID       *string `json:"_autoBracketGroupId"`
-	IsProcessed              *bool   `json:"isProcessed"`
-	PreviewURL               *string `json:"previewUrl"`
+	Name                      *string  `json:"name"`
+	FileURL                   *string  `json:"fileUrl"`
+	Size                      *int     `json:"size"`
+	DateTimeZone              *string  `json:"dateTimeZone"`
+	DateTime                  *string  `json:"dateTime"`
+	Lat                       *float64 `json:"lat"`
+	Lng                       *float64 `json:"lng"`
+	Width                     *int     `json:"width"`
+	Height                    *int     `json:"height"`
+	RecordTime                *int     `json:"_recordTime"`
+	Thumbnail                 []byte   `json:"_thumbnail"`
+	ThumbSize                 *int     `json:"_thumbSize"`
+	IntervalCaptureGroupID    *string  `json:"_intervalCaptureGroupId"`
+	CompositeShootingGroupID  *string  `json:"_compositeShootingGroupId"`
+	AutoBracketGroupID        *string  `json:"_autoBracketGroupId"`
+	ContinuousShootingGroupID *string  `json:"_continuousShootingGroupId"`
+	IsProcessed               *bool    `json:"isProcessed"`
+	PreviewURL                *string  `json:"previewUrl"`
+	Codec                     *string  `json:"_codec"`
+	ProjectionType            *string  `json:"_projectionType"`
+	FrameRate                 *int     `json:"_frameRate"`
+	Favorite                  *bool    `json:"_favorite"`
+	ImageDescription          *string  `json:"_imageDescription"`
+	StorageID                 *string  `json:"_storageID"`
 
 	// Deprecated in Theta API v2.1 (OSC v2.0).
 	RecordTimev20 *int    `json:"recordTime"`
 	URI           *string `json:"uri"`
-
-	// Not supported by Theta.
-	Lat *int `json:"lat"`
-	Lng *int `json:"lng"`
+	Thumbnailv20  []byte  `json:"thumbnail"`
 }
 
 func (e Entries) String() string {
 	return Stringify(e)
 }
 
+// Layouts of Entries.DateTimeZone and Entries.DateTime.
+const (
+	dateTimeZoneLayout = "2006:01:02 15:04:05-07:00"
+	dateTimeLayout     = "2006:01:02 15:04:05"
+)
+
+// Time returns the date and time the file was shot. DateTimeZone is used if
+// present, otherwise DateTime is interpreted in the local time zone.
+func (e *Entries) Time() (time.Time, error) {
+	switch {
+	case e.DateTimeZone != nil:
+		return time.Parse(dateTimeZoneLayout, *e.DateTimeZone)
+	case e.DateTime != nil:
+		return time.ParseInLocation(dateTimeLayout, *e.DateTime, time.Local)
+	}
+	return time.Time{}, errors.New("entry has no date")
+}
+
+// URL returns the fileUrl of the file in Theta API v2.1, or its uri in v2.0.
+func (e *Entries) URL() string {
+	switch {
+	case e.FileURL != nil:
+		return *e.FileURL
+	case e.URI != nil:
+		return *e.URI
+	}
+	return ""
+}
+
 // EXIF represents exif information.
 type EXIF struct {
 	EXIFVersion       *string  `json:"ExifVersion"`
@@ -136,7 +206,7 @@ func (e EXIF) String() string {
 	return Stringify(e)
 }
 
-// XMP represents exif information.
+// XMP represents the Photo Sphere XMP metadata.
 type XMP struct {
 	ProjectionType               *string `json:"ProjectionType"`
 	UsePanoramaViewer            *bool   `json:"UsePanoramaViewer"`
@@ -162,10 +232,18 @@ func (e Error) String() string {
 	return Stringify(e)
 }
 
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return e.Code
+	}
+	return e.Code + ": " + e.Message
+}
+
 // Progress represents parameters in progress.
 type Progress struct {
-	SessionID string `json:"sessionId"`
-	Timeout   int    `json:"timeout"`
+	Completion float64 `json:"completion"`
+	SessionID  string  `json:"sessionId"`
+	Timeout    int     `json:"timeout"`
 }
 
 func (p Progress) String() string {
@@ -175,7 +253,22 @@ func (p Progress) String() string {
 // CommandServices handles communication with the command methods of Theta API.
 type CommandServices service
 
-func (s *CommandServices) commandsExecute(ctx context.Context, body interface{}) (*CommandResponse, *http.Response, error) {
+func (s *CommandServices) commandsExecute(ctx context.Context, body CommandRequest) (*CommandResponse, *http.Response, error) {
+	cmd, resp, err := s.execute(ctx, body)
+	if errors.Is(err, ErrInvalidSessionID) && renewsSession(body) {
+		// The session has timed out. Start a new one and send the command again.
+		if _, _, err := s.StartSession(ctx); err != nil {
+			return nil, resp, err
+		}
+		parameters := *body.Parameters
+		parameters.SessionID = String(s.client.currentSessionID())
+		body.Parameters = &parameters
+		return s.execute(ctx, body)
+	}
+	return cmd, resp, err
+}
+
+func (s *CommandServices) execute(ctx context.Context, body CommandRequest) (*CommandResponse, *http.Response, error) {
 	req, err := s.client.NewRequest("POST", commandsExecuteURL, body)
 	if err != nil {
 		return nil, nil, err
@@ -185,6 +278,75 @@ func (s *CommandServices) commandsExecute(ctx context.Context, body interface{})
 	if err != nil {
 		return nil, resp, err
 	}
-	fmt.Printf("Name: %v\nState: %v\n\n", *(commandResponse.Name), *(commandResponse.State))
+	if err := checkCommandResponse(resp, commandResponse); err != nil {
+		return commandResponse, resp, err
+	}
+	return commandResponse, resp, nil
+}
+
+// renewsSession reports whether a new session should be started when the
+// camera rejects the session ID of body.
+func renewsSession(body CommandRequest) bool {
+	if body.Parameters == nil || body.Parameters.SessionID == nil || body.Name == nil {
+		return false
+	}
+	return *body.Name != "camera.closeSession"
+}
+
+// Status gets the status of the in-progress command identified by id.
+func (s *CommandServices) Status(ctx context.Context, id string) (*CommandResponse, *http.Response, error) {
+	req, err := s.client.NewRequest("POST", commandStatusURL, CommandRequest{ID: String(id)})
+	if err != nil {
+		return nil, nil, err
+	}
+	commandResponse := new(CommandResponse)
+	resp, err := s.client.Do(ctx, req, commandResponse)
+	if err != nil {
+		return nil, resp, err
+	}
+	if err := checkCommandResponse(resp, commandResponse); err != nil {
+		return commandResponse, resp, err
+	}
 	return commandResponse, resp, nil
 }
+
+// Wait polls the status of cmd every Client.PollInterval until the command is
+// no longer in progress, and returns its final response. A command which is
+// already done is returned as is. Wait returns ctx.Err() if ctx is done first.
+func (s *CommandServices) Wait(ctx context.Context, cmd *CommandResponse) (*CommandResponse, *http.Response, error) {
+	var resp *http.Response
+	for cmd.State != nil && *cmd.State == CommandStateInProgress {
+		if cmd.ID == nil {
+			return cmd, resp, errors.New("in-progress command has no id")
+		}
+		t := time.NewTimer(s.client.pollInterval())
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return cmd, resp, ctx.Err()
+		case <-t.C:
+		}
+		var err error
+		cmd, resp, err = s.Status(ctx, *cmd.ID)
+		if err != nil {
+			return cmd, resp, err
+		}
+	}
+	return cmd, resp, nil
+}
+
+// checkCommandResponse returns an *ErrorResponse if the camera reported an error
+// in the body of a successful HTTP response.
+func checkCommandResponse(r *http.Response, c *CommandResponse) error {
+	if c.Error == nil && (c.State == nil || *c.State != CommandStateError) {
+		return nil
+	}
+	if c.Error == nil {
+		return &ErrorResponse{Response: r}
+	}
+	return &ErrorResponse{
+		Response: r,
+		Code:     c.Error.Code,
+		Message:  c.Error.Message,
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright (c) 2017 "Shun Yokota" All rights reserved
+//
+// Part of the source code is adapted from https://github.com/google/go-github
+// Copyright 2013 The go-github AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package theta
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCommandServices_Status(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc(commandStatusURL, func(w http.ResponseWriter, r *http.Request) {
+		v := new(CommandRequest)
+		json.NewDecoder(r.Body).Decode(v)
+		if v.ID == nil || *v.ID != "1" {
+			t.Errorf("Request body id is %v, want 1", v.ID)
+		}
+		fmt.Fprint(w, `{"name":"camera.takePicture","state":"inProgress","id":"1","progress":{"completion":0.5}}`)
+	})
+
+	cmd, _, err := client.Command.Status(context.Background(), "1")
+	if err != nil {
+		t.Fatalf("Command.Status returned error: %v", err)
+	}
+	if got, want := *cmd.State, CommandStateInProgress; got != want {
+		t.Errorf("Command.Status State is %v, want %v", got, want)
+	}
+	if got, want := cmd.Progress.Completion, 0.5; got != want {
+		t.Errorf("Command.Status Progress.Completion is %v, want %v", got, want)
+	}
+}
+
+func TestCommandServices_Wait(t *testing.T) {
+	setup()
+	defer teardown()
+	client.PollInterval = time.Millisecond
+
+	polls := 0
+	mux.HandleFunc(commandStatusURL, func(w http.ResponseWriter, r *http.Request) {
+		polls++
+		if polls < 3 {
+			fmt.Fprint(w, `{"name":"camera.takePicture","state":"inProgress","id":"1"}`)
+			return
+		}
+		fmt.Fprint(w, `{"name":"camera.takePicture","state":"done","results":{"fileUrl":"http://192.168.1.1/files/R0010001.JPG"}}`)
+	})
+
+	in := &CommandResponse{State: String(CommandStateInProgress), ID: String("1")}
+	cmd, _, err := client.Command.Wait(context.Background(), in)
+	if err != nil {
+		t.Fatalf("Command.Wait returned error: %v", err)
+	}
+	if got, want := *cmd.State, CommandStateDone; got != want {
+		t.Errorf("Command.Wait State is %v, want %v", got, want)
+	}
+	if got, want := polls, 3; got != want {
+		t.Errorf("Command.Wait polled %v times, want %v", got, want)
+	}
+}
+
+func TestCommandServices_Wait_error(t *testing.T) {
+	setup()
+	defer teardown()
+	client.PollInterval = time.Millisecond
+
+	mux.HandleFunc(commandStatusURL, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"name":"camera.takePicture","state":"error","error":{"code":"serviceUnavailable","message":"busy"}}`)
+	})
+
+	in := &CommandResponse{State: String(CommandStateInProgress), ID: String("1")}
+	_, _, err := client.Command.Wait(context.Background(), in)
+	if err, ok := err.(*ErrorResponse); !ok || err.Code != "serviceUnavailable" {
+		t.Errorf("Command.Wait returned %#v, want *ErrorResponse with serviceUnavailable", err)
+	}
+}
+
+func TestCommandServices_Wait_canceled(t *testing.T) {
+	setup()
+	defer teardown()
+	client.PollInterval = time.Hour
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	in := &CommandResponse{State: String(CommandStateInProgress), ID: String("1")}
+	if _, _, err := client.Command.Wait(ctx, in); err != context.Canceled {
+		t.Errorf("Command.Wait returned %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestCommandServices_TakePicture(t *testing.T) {
+	setup()
+	defer teardown()
+	client.PollInterval = time.Millisecond
+	client.apiLevel = 2
+
+	mux.HandleFunc(commandsExecuteURL, func(w http.ResponseWriter, r *http.Request) {
+		v := new(CommandRequest)
+		json.NewDecoder(r.Body).Decode(v)
+		if got, want := *v.Name, "camera.takePicture"; got != want {
+			t.Errorf("Request name is %v, want %v", got, want)
+		}
+		if v.Parameters != nil {
+			t.Errorf("Request parameters is %v, want nil", v.Parameters)
+		}
+		fmt.Fprint(w, `{"name":"camera.takePicture","state":"inProgress","id":"2"}`)
+	})
+	mux.HandleFunc(commandStatusURL, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"name":"camera.takePicture","state":"done","results":{"fileUrl":"http://192.168.1.1/files/R0010001.JPG"}}`)
+	})
+
+	url, _, err := client.Command.TakePicture(context.Background())
+	if err != nil {
+		t.Fatalf("Command.TakePicture returned error: %v", err)
+	}
+	if got, want := url, "http://192.168.1.1/files/R0010001.JPG"; got != want {
+		t.Errorf("Command.TakePicture returned %v, want %v", got, want)
+	}
+}
+
+func TestCommandServices_TakePicture_v20(t *testing.T) {
+	setup()
+	defer teardown()
+	client.sessionID = "SID_0001"
+
+	mux.HandleFunc(commandsExecuteURL, func(w http.ResponseWriter, r *http.Request) {
+		v := new(CommandRequest)
+		json.NewDecoder(r.Body).Decode(v)
+		if v.Parameters == nil || v.Parameters.SessionID == nil || *v.Parameters.SessionID != "SID_0001" {
+			t.Errorf("Request parameters is %v, want sessionId SID_0001", v.Parameters)
+		}
+		fmt.Fprint(w, `{"name":"camera.takePicture","state":"done","results":{"fileUri":"100RICOH/R0010001.JPG"}}`)
+	})
+
+	uri, _, err := client.Command.TakePicture(context.Background())
+	if err != nil {
+		t.Fatalf("Command.TakePicture returned error: %v", err)
+	}
+	if got, want := uri, "100RICOH/R0010001.JPG"; got != want {
+		t.Errorf("Command.TakePicture returned %v, want %v", got, want)
+	}
+}
+
+func TestEntries_unmarshal(t *testing.T) {
+	data := `{"name":"R0010015.JPG","fileUrl":"http://192.168.1.1/files/150100525831424d42075b53ce68c300/100RICOH/R0010015.JPG",
+		"size":4051440,"dateTimeZone":"2015:07:10 11:05:18+09:00","lat":50.5324,"lng":-120.2332,
+		"width":5376,"height":2688,"_thumbnail":"/9j/2w==","_thumbSize":4,"_codec":"H.264/MPEG-4 AVC",
+		"_projectionType":"Equirectangular","_storageID":"sd"}`
+	e := new(Entries)
+	if err := json.Unmarshal([]byte(data), e); err != nil {
+		t.Fatalf("json.Unmarshal returned error: %v", err)
+	}
+	if got, want := *e.Lng, -120.2332; got != want {
+		t.Errorf("Entries.Lng is %v, want %v", got, want)
+	}
+	if got, want := e.Thumbnail, []byte{0xff, 0xd8, 0xff, 0xdb}; !bytes.Equal(got, want) {
+		t.Errorf("Entries.Thumbnail is %x, want %x", got, want)
+	}
+	if got, want := *e.ThumbSize, 4; got != want {
+		t.Errorf("Entries.ThumbSize is %v, want %v", got, want)
+	}
+	if got, want := *e.StorageID, "sd"; got != want {
+		t.Errorf("Entries.StorageID is %v, want %v", got, want)
+	}
+
+	got, err := e.Time()
+	if err != nil {
+		t.Fatalf("Entries.Time returned error: %v", err)
+	}
+	if want := time.Date(2015, 7, 10, 2, 5, 18, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Entries.Time returned %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright (c) 2017 "Shun Yokota" All rights reserved
+//
+// Part of the source code is adapted from https://github.com/google/go-github
+// Copyright 2013 The go-github AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package theta
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// errNotDeleted is reported for the files the camera has left undeleted.
+var errNotDeleted = errors.New("file was not deleted")
+
+// DeleteFileError reports a file which could not be deleted.
+type DeleteFileError struct {
+	URL string
+	Err error
+}
+
+func (e *DeleteFileError) Error() string {
+	return e.URL + ": " + e.Err.Error()
+}
+
+// DeleteError reports every file which could not be deleted by Delete.
+type DeleteError []*DeleteFileError
+
+func (e DeleteError) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%d files were not deleted: %s", len(e), strings.Join(msgs, "; "))
+}
+
+// Delete deletes the files at urls, which are the fileUrls of the files in
+// Theta API v2.1 or their fileUris in v2.0. The keywords FileTypeAll,
+// FileTypeImage and FileTypeVideo delete all files of the type.
+//
+// Theta API v2.0 deletes one file per request, so Delete sends a request per
+// file and goes on after a failure. The keywords are expanded with ListImages,
+// which lists still images only; FileTypeVideo is not supported.
+//
+// The returned error is a DeleteError if some of the files were not deleted.
+func (s *CommandServices) Delete(ctx context.Context, urls ...string) (*http.Response, error) {
+	if s.client.APILevel() == 1 {
+		return s.deletev20(ctx, urls)
+	}
+
+	body := CommandRequest{
+		Name:       String("camera.delete"),
+		Parameters: &Parameters{FileURLs: urls},
+	}
+	cmd, resp, err := s.commandsExecute(ctx, body)
+	if err != nil {
+		return resp, err
+	}
+	if cmd.Results != nil && len(cmd.Results.FileURLs) > 0 {
+		var errs DeleteError
+		for _, u := range cmd.Results.FileURLs {
+			errs = append(errs, &DeleteFileError{URL: u, Err: errNotDeleted})
+		}
+		return resp, errs
+	}
+	return resp, nil
+}
+
+func (s *CommandServices) deletev20(ctx context.Context, urls []string) (*http.Response, error) {
+	var uris []string
+	for _, u := range urls {
+		switch u {
+		case FileTypeAll, FileTypeImage:
+			it := s.Files(u)
+			for it.Next(ctx) {
+				uris = append(uris, it.Entry().URL())
+			}
+			if err := it.Err(); err != nil {
+				return nil, err
+			}
+		case FileTypeVideo:
+			return nil, errors.New("deleting all videos is not supported in Theta API v2.0")
+		default:
+			uris = append(uris, u)
+		}
+	}
+
+	var resp *http.Response
+	var errs DeleteError
+	for _, uri := range uris {
+		body := CommandRequest{
+			Name: String("camera.delete"),
+			Parameters: &Parameters{
+				SessionID: s.client.session(),
+				FileURI:   String(uri),
+			},
+		}
+		var err error
+		if _, resp, err = s.commandsExecute(ctx, body); err != nil {
+			if ctx.Err() != nil {
+				return resp, ctx.Err()
+			}
+			errs = append(errs, &DeleteFileError{URL: uri, Err: err})
+		}
+	}
+	if len(errs) > 0 {
+		return resp, errs
+	}
+	return resp, nil
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2017 "Shun Yokota" All rights reserved
+//
+// Part of the source code is adapted from https://github.com/google/go-github
+// Copyright 2013 The go-github AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package theta
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestCommandServices_Delete(t *testing.T) {
+	setup()
+	defer teardown()
+	client.apiLevel = 2
+
+	mux.HandleFunc(commandsExecuteURL, func(w http.ResponseWriter, r *http.Request) {
+		v := new(CommandRequest)
+		json.NewDecoder(r.Body).Decode(v)
+		want := []string{"http://192.168.1.1/files/R0010001.JPG", FileTypeVideo}
+		if got := v.Parameters.FileURLs; !reflect.DeepEqual(got, want) {
+			t.Errorf("Request fileUrls is %v, want %v", got, want)
+		}
+		fmt.Fprint(w, `{"name":"camera.delete","state":"done","results":{"fileUrls":["http://192.168.1.1/files/R0010002.MP4"]}}`)
+	})
+
+	_, err := client.Command.Delete(context.Background(), "http://192.168.1.1/files/R0010001.JPG", FileTypeVideo)
+	errs, ok := err.(DeleteError)
+	if !ok || len(errs) != 1 || errs[0].URL != "http://192.168.1.1/files/R0010002.MP4" {
+		t.Errorf("Command.Delete returned %#v, want DeleteError for R0010002.MP4", err)
+	}
+}
+
+func TestCommandServices_Delete_v20(t *testing.T) {
+	setup()
+	defer teardown()
+	client.sessionID = "SID_0001"
+
+	var uris []string
+	mux.HandleFunc(commandsExecuteURL, func(w http.ResponseWriter, r *http.Request) {
+		v := new(CommandRequest)
+		json.NewDecoder(r.Body).Decode(v)
+		uri := *v.Parameters.FileURI
+		uris = append(uris, uri)
+		if uri == "100RICOH/R0010002.JPG" {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"name":"camera.delete","state":"error","error":{"code":"invalidParameterValue","message":"no file"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"name":"camera.delete","state":"done"}`)
+	})
+
+	_, err := client.Command.Delete(context.Background(), "100RICOH/R0010001.JPG", "100RICOH/R0010002.JPG", "100RICOH/R0010003.JPG")
+	if want := []string{"100RICOH/R0010001.JPG", "100RICOH/R0010002.JPG", "100RICOH/R0010003.JPG"}; !reflect.DeepEqual(uris, want) {
+		t.Errorf("Command.Delete sent %v, want %v", uris, want)
+	}
+	errs, ok := err.(DeleteError)
+	if !ok || len(errs) != 1 || errs[0].URL != "100RICOH/R0010002.JPG" {
+		t.Fatalf("Command.Delete returned %#v, want DeleteError for R0010002.JPG", err)
+	}
+	if e, ok := errs[0].Err.(*ErrorResponse); !ok || e.Code != "invalidParameterValue" {
+		t.Errorf("DeleteError has %#v, want invalidParameterValue", errs[0].Err)
+	}
+}
@@ -0,0 +1,207 @@
+// Copyright (c) 2017 "Shun Yokota" All rights reserved
+//
+// Part of the source code is adapted from https://github.com/google/go-github
+// Copyright 2013 The go-github AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package theta
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultDownloadRetries is the number of times DownloadToFile resumes a
+// download by default.
+const defaultDownloadRetries = 3
+
+// ErrSizeMismatch is returned by DownloadToFile when the size of the downloaded
+// file differs from the expected one.
+var ErrSizeMismatch = errors.New("size of downloaded file does not match")
+
+// DownloadOptions specifies the optional parameters to DownloadToFile.
+type DownloadOptions struct {
+	// Size is the expected size of the file in bytes, usually Entries.Size.
+	// The downloaded file is checked against it unless it is 0.
+	Size int64
+
+	// Progress, if set, is called with the number of bytes written so far and
+	// the total size, which is 0 if unknown.
+	Progress func(written, total int64)
+
+	// Retries is the number of times the download is resumed after an error.
+	// defaultDownloadRetries is used if it is 0, and no retry is made if it is
+	// negative.
+	Retries int
+}
+
+// Download writes the file at fileURL, such as Entries.FileURL, to w.
+func (c *Client) Download(ctx context.Context, fileURL string, w io.Writer) (*http.Response, error) {
+	req, err := c.newDownloadRequest(fileURL, 0)
+	if err != nil {
+		return nil, err
+	}
+	return c.Do(ctx, req, w)
+}
+
+// DownloadToFile downloads the file at fileURL to the file name. If the file
+// name already has a part of the file, for example from an interrupted
+// download, the rest of the file is requested with an HTTP Range request. A
+// download which fails halfway is resumed in the same way up to opt.Retries
+// times.
+func (c *Client) DownloadToFile(ctx context.Context, fileURL, name string, opt *DownloadOptions) error {
+	if opt == nil {
+		opt = new(DownloadOptions)
+	}
+	retries := opt.Retries
+	if retries == 0 {
+		retries = defaultDownloadRetries
+	}
+
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	offset, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	if opt.Size > 0 && offset > opt.Size {
+		// Not a part of this file.
+		if offset, err = truncate(f); err != nil {
+			return err
+		}
+	}
+
+	total := opt.Size
+	for attempt := 0; opt.Size == 0 || offset < opt.Size; attempt++ {
+		var n int64
+		n, total, err = c.downloadFrom(ctx, f, fileURL, offset, total, opt.Progress)
+		offset += n
+		if err == errRangeIgnored {
+			if offset, err = truncate(f); err != nil {
+				return err
+			}
+			continue
+		}
+		if err == nil || ctx.Err() != nil || attempt >= retries {
+			break
+		}
+
+		t := time.NewTimer(c.pollInterval())
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if opt.Size > 0 && offset != opt.Size {
+		return fmt.Errorf("%w: %v: got %d bytes, want %d", ErrSizeMismatch, name, offset, opt.Size)
+	}
+	return nil
+}
+
+// errRangeIgnored is returned by downloadFrom when the camera sent the whole
+// file instead of the requested range.
+var errRangeIgnored = errors.New("range request ignored")
+
+// downloadFrom appends the file at fileURL to w from offset, and returns the
+// number of bytes written and the total size of the file if known.
+func (c *Client) downloadFrom(ctx context.Context, w io.Writer, fileURL string, offset, total int64, progress func(written, total int64)) (int64, int64, error) {
+	req, err := c.newDownloadRequest(fileURL, offset)
+	if err != nil {
+		return 0, total, err
+	}
+	resp, err := c.bareDo(ctx, req)
+	if err != nil {
+		if e, ok := err.(*ErrorResponse); ok && offset > 0 &&
+			e.Response.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+			// The file has been downloaded completely.
+			return 0, total, nil
+		}
+		return 0, total, err
+	}
+	defer resp.Body.Close()
+
+	if offset > 0 && resp.StatusCode != http.StatusPartialContent {
+		return 0, total, errRangeIgnored
+	}
+	if total == 0 {
+		total = contentSize(resp, offset)
+	}
+
+	pw := &progressWriter{w: w, written: offset, total: total, progress: progress}
+	_, err = io.Copy(pw, resp.Body)
+	if err != nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	return pw.written - offset, total, err
+}
+
+func (c *Client) newDownloadRequest(fileURL string, offset int64) (*http.Request, error) {
+	req, err := c.NewRequest("GET", fileURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "*/*")
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	return req, nil
+}
+
+// contentSize returns the size of the whole file from the Content-Range or
+// Content-Length of resp, or 0 if unknown.
+func contentSize(resp *http.Response, offset int64) int64 {
+	if cr := resp.Header.Get("Content-Range"); cr != "" {
+		if i := strings.LastIndex(cr, "/"); i >= 0 {
+			if n, err := strconv.ParseInt(cr[i+1:], 10, 64); err == nil {
+				return n
+			}
+		}
+	}
+	if resp.ContentLength >= 0 {
+		return offset + resp.ContentLength
+	}
+	return 0
+}
+
+func truncate(f *os.File) (int64, error) {
+	if err := f.Truncate(0); err != nil {
+		return 0, err
+	}
+	return f.Seek(0, io.SeekStart)
+}
+
+// progressWriter counts the bytes written to w and reports them to progress.
+type progressWriter struct {
+	w        io.Writer
+	written  int64
+	total    int64
+	progress func(written, total int64)
+}
+
+func (pw *progressWriter) Write(p []byte) (int, error) {
+	n, err := pw.w.Write(p)
+	pw.written += int64(n)
+	if pw.progress != nil {
+		pw.progress(pw.written, pw.total)
+	}
+	return n, err
+}
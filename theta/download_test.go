@@ -0,0 +1,107 @@
+// Copyright (c) 2017 "Shun Yokota" All rights reserved
+//
+// Part of the source code is adapted from https://github.com/google/go-github
+// Copyright 2013 The go-github AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package theta
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestClient_Download(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/files/R0010001.JPG", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("jpeg"))
+	})
+
+	var buf bytes.Buffer
+	if _, err := client.Download(context.Background(), server.URL+"/files/R0010001.JPG", &buf); err != nil {
+		t.Fatalf("Download returned error: %v", err)
+	}
+	if got, want := buf.String(), "jpeg"; got != want {
+		t.Errorf("Download wrote %q, want %q", got, want)
+	}
+}
+
+func TestClient_DownloadToFile_resume(t *testing.T) {
+	setup()
+	defer teardown()
+	client.PollInterval = time.Millisecond
+
+	content := bytes.Repeat([]byte("0123456789"), 1000)
+	var ranges []string
+	mux.HandleFunc("/files/R0010001.MP4", func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		if len(ranges) == 1 {
+			// drop the connection halfway
+			w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+			w.Write(content[:4000])
+			return
+		}
+		http.ServeContent(w, r, "R0010001.MP4", time.Time{}, bytes.NewReader(content))
+	})
+
+	dir, err := ioutil.TempDir("", "theta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	name := filepath.Join(dir, "R0010001.MP4")
+
+	var last int64
+	opt := &DownloadOptions{
+		Size:     int64(len(content)),
+		Progress: func(written, total int64) { last = written },
+	}
+	if err := client.DownloadToFile(context.Background(), server.URL+"/files/R0010001.MP4", name, opt); err != nil {
+		t.Fatalf("DownloadToFile returned error: %v", err)
+	}
+
+	got, _ := ioutil.ReadFile(name)
+	if !bytes.Equal(got, content) {
+		t.Errorf("DownloadToFile wrote %d bytes, want the %d bytes of the file", len(got), len(content))
+	}
+	if want := []string{"", "bytes=4000-"}; len(ranges) != 2 || ranges[1] != want[1] {
+		t.Errorf("DownloadToFile requested ranges %q, want %q", ranges, want)
+	}
+	if last != int64(len(content)) {
+		t.Errorf("DownloadToFile reported progress %d, want %d", last, len(content))
+	}
+}
+
+func TestClient_DownloadToFile_sizeMismatch(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc("/files/R0010001.JPG", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("jpeg"))
+	})
+
+	dir, err := ioutil.TempDir("", "theta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "R0010001.JPG")
+	opt := &DownloadOptions{Size: 3, Retries: -1}
+	err = client.DownloadToFile(context.Background(), server.URL+"/files/R0010001.JPG", name, opt)
+	if !errors.Is(err, ErrSizeMismatch) {
+		t.Errorf("DownloadToFile returned %v, want ErrSizeMismatch", err)
+	}
+}
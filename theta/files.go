@@ -0,0 +1,192 @@
+// Copyright (c) 2017 "Shun Yokota" All rights reserved
+//
+// Part of the source code is adapted from https://github.com/google/go-github
+// Copyright 2013 The go-github AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package theta
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// File types passed to ListFiles.
+const (
+	FileTypeAll   = "all"
+	FileTypeImage = "image"
+	FileTypeVideo = "video"
+)
+
+// fileListPageSize is the number of entries requested at a time by FileIterator.
+const fileListPageSize = 100
+
+// ListFilesOptions specifies the parameters to ListFiles.
+type ListFilesOptions struct {
+	FileType      string // FileTypeAll, FileTypeImage or FileTypeVideo
+	StartPosition int
+	EntryCount    int
+
+	// MaxThumbSize is the maximum size of the thumbnails. Thumbnails are not
+	// returned if it is 0.
+	MaxThumbSize int
+
+	// Detail requests the details of each file, such as the group IDs, which
+	// the camera returns if it is nil. Only the basic entries are returned if
+	// it is false, which is faster.
+	Detail *bool
+}
+
+// ListFiles lists the still image and video files in the camera. ListFiles
+// requires Theta API v2.1 (OSC v2.0).
+func (s *CommandServices) ListFiles(ctx context.Context, opt *ListFilesOptions) (*Results, *http.Response, error) {
+	fileType := opt.FileType
+	if fileType == "" {
+		fileType = FileTypeAll
+	}
+	body := CommandRequest{
+		Name: String("camera.listFiles"),
+		Parameters: &Parameters{
+			FileType:      String(fileType),
+			StartPosition: Int(opt.StartPosition),
+			EntryCount:    Int(opt.EntryCount),
+			MaxThumbSize:  Int(opt.MaxThumbSize),
+			Detail:        opt.Detail,
+		},
+	}
+	return s.listResults(ctx, body)
+}
+
+// ListImagesOptions specifies the parameters to ListImages.
+type ListImagesOptions struct {
+	EntryCount int
+
+	// ContinuationToken is the token returned by the previous ListImages to
+	// get the next entries.
+	ContinuationToken string
+
+	// IncludeThumb requests thumbnails of at most MaxSize.
+	IncludeThumb bool
+	MaxSize      int
+}
+
+// ListImages lists the still image files in the camera. ListImages is deprecated
+// in Theta API v2.1 (OSC v2.0).
+func (s *CommandServices) ListImages(ctx context.Context, opt *ListImagesOptions) (*Results, *http.Response, error) {
+	parameters := &Parameters{
+		SessionID:    s.client.session(),
+		EntryCount:   Int(opt.EntryCount),
+		IncludeThumb: Bool(opt.IncludeThumb),
+	}
+	if opt.IncludeThumb {
+		parameters.MaxSize = Int(opt.MaxSize)
+	}
+	if opt.ContinuationToken != "" {
+		parameters.ContinuationToken = String(opt.ContinuationToken)
+	}
+	body := CommandRequest{
+		Name:       String("camera.listImages"),
+		Parameters: parameters,
+	}
+	return s.listResults(ctx, body)
+}
+
+func (s *CommandServices) listResults(ctx context.Context, body CommandRequest) (*Results, *http.Response, error) {
+	cmd, resp, err := s.commandsExecute(ctx, body)
+	if err != nil {
+		return nil, resp, err
+	}
+	if cmd.Results == nil {
+		return nil, resp, errors.New(*body.Name + " returned no results")
+	}
+	return cmd.Results, resp, nil
+}
+
+// FileIterator walks the files in the camera page by page. It uses ListFiles in
+// Theta API v2.1 and ListImages in v2.0, which lists still images only.
+//
+//	it := client.Command.Files(theta.FileTypeAll)
+//	for it.Next(ctx) {
+//		entry := it.Entry()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type FileIterator struct {
+	s        *CommandServices
+	fileType string
+
+	entries  []*Entries
+	entry    *Entries
+	position int    // start position of the next page in v2.1
+	token    string // continuation token of the next page in v2.0
+	last     bool   // no page after the current one
+	err      error
+}
+
+// Files returns an iterator over the files of fileType in the camera.
+func (s *CommandServices) Files(fileType string) *FileIterator {
+	return &FileIterator{s: s, fileType: fileType}
+}
+
+// Next advances to the next file, fetching the next page from the camera when
+// needed. It returns false when there are no more files or an error occurred.
+func (it *FileIterator) Next(ctx context.Context) bool {
+	for len(it.entries) == 0 {
+		if it.last || it.err != nil {
+			it.entry = nil
+			return false
+		}
+		it.err = it.fetch(ctx)
+	}
+	it.entry, it.entries = it.entries[0], it.entries[1:]
+	return true
+}
+
+// Entry returns the current file.
+func (it *FileIterator) Entry() *Entries {
+	return it.entry
+}
+
+// Err returns the error, if any, that was encountered during iteration.
+func (it *FileIterator) Err() error {
+	return it.err
+}
+
+func (it *FileIterator) fetch(ctx context.Context) error {
+	if it.s.client.APILevel() == 1 {
+		results, _, err := it.s.ListImages(ctx, &ListImagesOptions{
+			EntryCount:        fileListPageSize,
+			ContinuationToken: it.token,
+		})
+		if err != nil {
+			return err
+		}
+		it.entries = results.Entries
+		it.token = ""
+		if results.ContinuationToken != nil {
+			it.token = *results.ContinuationToken
+		}
+		it.last = it.token == ""
+		return nil
+	}
+
+	results, _, err := it.s.ListFiles(ctx, &ListFilesOptions{
+		FileType:      it.fileType,
+		StartPosition: it.position,
+		EntryCount:    fileListPageSize,
+		Detail:        Bool(true),
+	})
+	if err != nil {
+		return err
+	}
+	it.entries = results.Entries
+	it.position += len(results.Entries)
+	it.last = len(results.Entries) == 0 ||
+		results.TotalEntries != nil && it.position >= *results.TotalEntries
+	return nil
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2017 "Shun Yokota" All rights reserved
+//
+// Part of the source code is adapted from https://github.com/google/go-github
+// Copyright 2013 The go-github AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package theta
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFileIterator(t *testing.T) {
+	setup()
+	defer teardown()
+	client.apiLevel = 2
+
+	mux.HandleFunc(commandsExecuteURL, func(w http.ResponseWriter, r *http.Request) {
+		v := new(CommandRequest)
+		json.NewDecoder(r.Body).Decode(v)
+		if got, want := *v.Name, "camera.listFiles"; got != want {
+			t.Errorf("Request name is %v, want %v", got, want)
+		}
+		if d := v.Parameters.Detail; d == nil || !*d {
+			t.Errorf("Request _detail is %v, want true", d)
+		}
+		start := *v.Parameters.StartPosition
+		if start >= 3 {
+			t.Fatalf("Request startPosition is %v, want less than 3", start)
+		}
+		// two entries per page
+		var names []string
+		for i := start; i < start+2 && i < 3; i++ {
+			names = append(names, fmt.Sprintf(`{"name":"R%07d.JPG"}`, i))
+		}
+		fmt.Fprintf(w, `{"name":"camera.listFiles","state":"done","results":{"entries":[%s],"totalEntries":3}}`,
+			strings.Join(names, ","))
+	})
+
+	var names []string
+	it := client.Command.Files(FileTypeImage)
+	for it.Next(context.Background()) {
+		names = append(names, *it.Entry().Name)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("FileIterator returned error: %v", err)
+	}
+	want := []string{"R0000000.JPG", "R0000001.JPG", "R0000002.JPG"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("FileIterator returned %v, want %v", names, want)
+	}
+}
+
+func TestFileIterator_v20(t *testing.T) {
+	setup()
+	defer teardown()
+	client.sessionID = "SID_0001"
+
+	mux.HandleFunc(commandsExecuteURL, func(w http.ResponseWriter, r *http.Request) {
+		v := new(CommandRequest)
+		json.NewDecoder(r.Body).Decode(v)
+		if got, want := *v.Name, "camera.listImages"; got != want {
+			t.Errorf("Request name is %v, want %v", got, want)
+		}
+		if v.Parameters.ContinuationToken == nil {
+			fmt.Fprint(w, `{"name":"camera.listImages","state":"done","results":{"entries":[{"name":"R0010001.JPG"}],"totalEntries":2,"continuationToken":"1"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"name":"camera.listImages","state":"done","results":{"entries":[{"name":"R0010002.JPG"}],"totalEntries":2}}`)
+	})
+
+	var names []string
+	it := client.Command.Files(FileTypeAll)
+	for it.Next(context.Background()) {
+		names = append(names, *it.Entry().Name)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("FileIterator returned error: %v", err)
+	}
+	want := []string{"R0010001.JPG", "R0010002.JPG"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("FileIterator returned %v, want %v", names, want)
+	}
+}
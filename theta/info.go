@@ -10,7 +10,6 @@ package theta
 
 import (
 	"context"
-	"fmt"
 	"net/http"
 )
 
@@ -32,13 +31,44 @@ type Info struct {
 	} `json:"endpoints"`
 }
 
+func (i Info) String() string {
+	return Stringify(i)
+}
+
 // InfoServices handles communication with the info of connected Theta.
 type InfoServices service
 
 // Get the Theta information.
 func (s *InfoServices) Get(ctx context.Context) (*Info, *http.Response, error) {
-	var info *Info
-	ep := info.Endpoints
-	fmt.Println(ep.HTTPPort)
-	return info, nil, nil
+	req, err := s.client.NewRequest("GET", infoURL, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	info := new(Info)
+	resp, err := s.client.Do(ctx, req, info)
+	if err != nil {
+		return nil, resp, err
+	}
+	return info, resp, nil
+}
+
+// SupportsAPILevel reports whether the camera supports the API level.
+func (i *Info) SupportsAPILevel(level int) bool {
+	for _, l := range i.Endpoints.APILevel {
+		if l == level {
+			return true
+		}
+	}
+	return false
+}
+
+// minAPILevel returns the lowest API level in levels, or 0 if levels is empty.
+func minAPILevel(levels []int) int {
+	min := 0
+	for _, l := range levels {
+		if min == 0 || l < min {
+			min = l
+		}
+	}
+	return min
 }
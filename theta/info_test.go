@@ -0,0 +1,48 @@
+// Copyright (c) 2017 "Shun Yokota" All rights reserved
+//
+// Part of the source code is adapted from https://github.com/google/go-github
+// Copyright 2013 The go-github AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package theta
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestInfoServices_Get(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc(infoURL, func(w http.ResponseWriter, r *http.Request) {
+		if got, want := r.Method, "GET"; got != want {
+			t.Errorf("Request method: %v, want %v", got, want)
+		}
+		fmt.Fprint(w, `{"manufacturer":"RICOH","model":"RICOH THETA S","apiLevel":[1,2],
+			"endpoints":{"httpPort":80,"httpUpdatesPort":80,"apiLevel":[1,2]}}`)
+	})
+
+	client.apiLevel = 2
+	info, _, err := client.Info.Get(context.Background())
+	if err != nil {
+		t.Fatalf("Info.Get returned error: %v", err)
+	}
+	if got, want := info.Model, "RICOH THETA S"; got != want {
+		t.Errorf("Info.Get Model is %v, want %v", got, want)
+	}
+	if got, want := info.Endpoints.APILevel, []int{1, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Info.Get Endpoints.APILevel is %v, want %v", got, want)
+	}
+	if got, want := client.apiLevel, 2; got != want {
+		t.Errorf("Info.Get changed apiLevel to %v, want %v", got, want)
+	}
+	if !info.SupportsAPILevel(2) {
+		t.Errorf("Info.SupportsAPILevel(2) returned false, want true")
+	}
+}
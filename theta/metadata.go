@@ -0,0 +1,70 @@
+// Copyright (c) 2017 "Shun Yokota" All rights reserved
+//
+// Part of the source code is adapted from https://github.com/google/go-github
+// Copyright 2013 The go-github AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package theta
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// Metadata represents the metadata of a still image.
+type Metadata struct {
+	EXIF *EXIF
+	XMP  *XMP
+}
+
+func (m Metadata) String() string {
+	return Stringify(m)
+}
+
+// GetMetadata gets the EXIF and XMP metadata of the still image at fileURL,
+// which is the fileUrl of the image in Theta API v2.1 or its fileUri in v2.0.
+func (s *CommandServices) GetMetadata(ctx context.Context, fileURL string) (*Metadata, *http.Response, error) {
+	parameters := &Parameters{SessionID: s.client.session()}
+	if parameters.SessionID != nil {
+		parameters.FileURI = String(fileURL)
+	} else {
+		parameters.FileURL = String(fileURL)
+	}
+	body := CommandRequest{
+		Name:       String("camera.getMetadata"),
+		Parameters: parameters,
+	}
+	cmd, resp, err := s.commandsExecute(ctx, body)
+	if err != nil {
+		return nil, resp, err
+	}
+	if cmd.Results == nil {
+		return nil, resp, errors.New("getMetadata returned no results")
+	}
+	return &Metadata{EXIF: cmd.Results.EXIF, XMP: cmd.Results.XMP}, resp, nil
+}
+
+// Latitude returns the latitude in signed decimal degrees, which is negative in
+// the southern hemisphere. ok is false if the image has no latitude.
+func (e *EXIF) Latitude() (lat float64, ok bool) {
+	return signedDegrees(e.GPSLatitude, e.GPSLatitudeRef, "S")
+}
+
+// Longitude returns the longitude in signed decimal degrees, which is negative
+// in the western hemisphere. ok is false if the image has no longitude.
+func (e *EXIF) Longitude() (lng float64, ok bool) {
+	return signedDegrees(e.GPSLongitude, e.GPSLongitudeRef, "W")
+}
+
+func signedDegrees(v *float64, ref *string, negative string) (float64, bool) {
+	if v == nil {
+		return 0, false
+	}
+	if ref != nil && *ref == negative && *v > 0 {
+		return -*v, true
+	}
+	return *v, true
+}
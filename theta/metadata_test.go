@@ -0,0 +1,52 @@
+// Copyright (c) 2017 "Shun Yokota" All rights reserved
+//
+// Part of the source code is adapted from https://github.com/google/go-github
+// Copyright 2013 The go-github AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package theta
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestCommandServices_GetMetadata(t *testing.T) {
+	setup()
+	defer teardown()
+	client.apiLevel = 2
+
+	mux.HandleFunc(commandsExecuteURL, func(w http.ResponseWriter, r *http.Request) {
+		v := new(CommandRequest)
+		json.NewDecoder(r.Body).Decode(v)
+		if got, want := *v.Parameters.FileURL, "http://192.168.1.1/files/R0010001.JPG"; got != want {
+			t.Errorf("Request fileUrl is %v, want %v", got, want)
+		}
+		fmt.Fprint(w, `{"name":"camera.getMetadata","state":"done","results":{
+			"exif":{"ExposureTime":0.004,"ISOSpeedRatings":100,"GPSLatitudeRef":"S","GPSLatitude":33.8568,
+				"GPSLongitudeRef":"E","GPSLongitude":151.2153},
+			"xmp":{"ProjectionType":"equirectangular","FullPanoWidthPixels":5376,"FullPanoHeightPixels":2688}}}`)
+	})
+
+	m, _, err := client.Command.GetMetadata(context.Background(), "http://192.168.1.1/files/R0010001.JPG")
+	if err != nil {
+		t.Fatalf("Command.GetMetadata returned error: %v", err)
+	}
+	if got, want := *m.EXIF.ExposureTime, 0.004; got != want {
+		t.Errorf("EXIF.ExposureTime is %v, want %v", got, want)
+	}
+	if got, want := *m.XMP.FullPanoWidthPixels, 5376; got != want {
+		t.Errorf("XMP.FullPanoWidthPixels is %v, want %v", got, want)
+	}
+	if lat, ok := m.EXIF.Latitude(); !ok || lat != -33.8568 {
+		t.Errorf("EXIF.Latitude returned %v, %v, want -33.8568, true", lat, ok)
+	}
+	if lng, ok := m.EXIF.Longitude(); !ok || lng != 151.2153 {
+		t.Errorf("EXIF.Longitude returned %v, %v, want 151.2153, true", lng, ok)
+	}
+}
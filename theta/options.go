@@ -10,42 +10,553 @@ package theta
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"strings"
+)
+
+// Names of the options passed to GetOptions.
+const (
+	OptionAperture                               = "aperture"
+	OptionApertureSupport                        = "apertureSupport"
+	OptionAutoBracket                            = "_autoBracket"
+	OptionAutoBracketSupport                     = "_autoBracketSupport"
+	OptionBitrate                                = "_bitrate"
+	OptionBitrateSupport                         = "_bitrateSupport"
+	OptionBluetoothPower                         = "_bluetoothPower"
+	OptionBluetoothPowerSupport                  = "_bluetoothPowerSupport"
+	OptionCaptureInterval                        = "captureInterval"
+	OptionCaptureIntervalSupport                 = "captureIntervalSupport"
+	OptionCaptureMode                            = "captureMode"
+	OptionCaptureModeSupport                     = "captureModeSupport"
+	OptionCaptureNumber                          = "captureNumber"
+	OptionCaptureNumberSupport                   = "captureNumberSupport"
+	OptionClientVersion                          = "clientVersion"
+	OptionColorTemperature                       = "_colorTemperature"
+	OptionColorTemperatureSupport                = "_colorTemperatureSupport"
+	OptionCompositeShootingOutputInterval        = "_compositeShootingOutputInterval"
+	OptionCompositeShootingOutputIntervalSupport = "_compositeShootingOutputIntervalSupport"
+	OptionCompositeShootingTime                  = "_compositeShootingTime"
+	OptionCompositeShootingTimeSupport           = "_compositeShootingTimeSupport"
+	OptionDateTimeZone                           = "dateTimeZone"
+	OptionExposureCompensation                   = "exposureCompensation"
+	OptionExposureCompensationSupport            = "exposureCompensationSupport"
+	OptionExposureDelay                          = "exposureDelay"
+	OptionExposureDelaySupport                   = "exposureDelaySupport"
+	OptionExposureProgram                        = "exposureProgram"
+	OptionExposureProgramSupport                 = "exposureProgramSupport"
+	OptionFileFormat                             = "fileFormat"
+	OptionFileFormatSupport                      = "fileFormatSupport"
+	OptionFilter                                 = "_filter"
+	OptionFilterSupport                          = "_filterSupport"
+	OptionGPSInfo                                = "gpsInfo"
+	OptionGPSTagRecording                        = "_gpsTagRecording"
+	OptionGPSTagRecordingSupport                 = "_gpsTagRecordingSupport"
+	OptionImageStitching                         = "_imageStitching"
+	OptionImageStitchingSupport                  = "_imageStitchingSupport"
+	OptionISO                                    = "iso"
+	OptionISOSupport                             = "isoSupport"
+	OptionISOAutoHighLimit                       = "isoAutoHighLimit"
+	OptionISOAutoHighLimitSupport                = "isoAutoHighLimitSupport"
+	OptionLanguage                               = "_language"
+	OptionLanguageSupport                        = "_languageSupport"
+	OptionMaxRecordableTime                      = "_maxRecordableTime"
+	OptionMaxRecordableTimeSupport               = "_maxRecordableTimeSupport"
+	OptionMicrophone                             = "_microphone"
+	OptionMicrophoneSupport                      = "_microphoneSupport"
+	OptionMicrophoneChannel                      = "_microphoneChannel"
+	OptionMicrophoneChannelSupport               = "_microphoneChannelSupport"
+	OptionOffDelay                               = "offDelay"
+	OptionOffDelaySupport                        = "offDelaySupport"
+	OptionPreviewFormat                          = "previewFormat"
+	OptionPreviewFormatSupport                   = "previewFormatSupport"
+	OptionRemainingPictures                      = "remainingPictures"
+	OptionRemainingSpace                         = "remainingSpace"
+	OptionRemainingVideoSeconds                  = "_remainingVideoSeconds"
+	OptionShootingMethod                         = "_shootingMethod"
+	OptionShootingMethodSupport                  = "_shootingMethodSupport"
+	OptionShutterSpeed                           = "shutterSpeed"
+	OptionShutterSpeedSupport                    = "shutterSpeedSupport"
+	OptionShutterVolume                          = "_shutterVolume"
+	OptionShutterVolumeSupport                   = "_shutterVolumeSupport"
+	OptionSleepDelay                             = "sleepDelay"
+	OptionSleepDelaySupport                      = "sleepDelaySupport"
+	OptionTopBottomCorrection                    = "_topBottomCorrection"
+	OptionTopBottomCorrectionSupport             = "_topBottomCorrectionSupport"
+	OptionTopBottomCorrectionRotation            = "_topBottomCorrectionRotation"
+	OptionTotalSpace                             = "totalSpace"
+	OptionVideoStitching                         = "_videoStitching"
+	OptionVideoStitchingSupport                  = "_videoStitchingSupport"
+	OptionVisibilityReduction                    = "_visibilityReduction"
+	OptionVisibilityReductionSupport             = "_visibilityReductionSupport"
+	OptionWhiteBalance                           = "whiteBalance"
+	OptionWhiteBalanceSupport                    = "whiteBalanceSupport"
+	OptionWhiteBalanceAutoStrength               = "_whiteBalanceAutoStrength"
+	OptionWhiteBalanceAutoStrengthSupport        = "_whiteBalanceAutoStrengthSupport"
+	OptionWLANChannel                            = "_wlanChannel"
+	OptionWLANChannelSupport                     = "_wlanChannelSupport"
+
+	// Deprecated in Theta API v2.1 (OSC v2.0).
+	OptionCaptureIntervalv20        = "_captureInterval"
+	OptionCaptureIntervalSupportv20 = "_captureIntervalSupport"
+	OptionCaptureNumberv20          = "_captureNumber"
+	OptionCaptureNumberSupportv20   = "_captureNumberSupport"
+	OptionHDMIReso                  = "_HDMIreso"
+	OptionHDMIResoSupport           = "_HDMIresoSupport"
+)
+
+// Values of Options.ExposureProgram.
+const (
+	ExposureProgramManual          = 1
+	ExposureProgramNormal          = 2
+	ExposureProgramShutterPriority = 4
+	ExposureProgramISOPriority     = 9
 )
 
 // Options represents Theta options.
 type Options struct {
-	Aparture               *float64  `json:"aparture,omitempty"`
-	ApartureSupport        []float64 `json:"apartureSupport,omitempty"`
-	AutoBracket            *Bracket  `json:"_autoBracket,omitempty"`
-	AutoBracketSupport     []int     `json:"_autoBracketSupport,omitempty"`
-	CaptureInterval        *int      `json:"_captureInterval,omitempty"`
-	CaptureIntervalSupport []int     `json:"_captureIntervalSupport,omitempty"`
-	ClientVersion          *int      `json:"clientVersion,omitempty"`
+	Aperture                               *float64                     `json:"aperture,omitempty"`
+	ApertureSupport                        []float64                    `json:"apertureSupport,omitempty"`
+	AutoBracket                            *Bracket                     `json:"_autoBracket,omitempty"`
+	AutoBracketSupport                     []int                        `json:"_autoBracketSupport,omitempty"`
+	Bitrate                                *string                      `json:"_bitrate,omitempty"`
+	BitrateSupport                         []string                     `json:"_bitrateSupport,omitempty"`
+	BluetoothPower                         *string                      `json:"_bluetoothPower,omitempty"`
+	BluetoothPowerSupport                  []string                     `json:"_bluetoothPowerSupport,omitempty"`
+	CaptureInterval                        *int                         `json:"captureInterval,omitempty"`
+	CaptureIntervalSupport                 *IntervalSupport             `json:"captureIntervalSupport,omitempty"`
+	CaptureMode                            *string                      `json:"captureMode,omitempty"`
+	CaptureModeSupport                     []string                     `json:"captureModeSupport,omitempty"`
+	CaptureNumber                          *int                         `json:"captureNumber,omitempty"`
+	CaptureNumberSupport                   *NumberSupport               `json:"captureNumberSupport,omitempty"`
+	ClientVersion                          *int                         `json:"clientVersion,omitempty"`
+	ColorTemperature                       *int                         `json:"_colorTemperature,omitempty"`
+	ColorTemperatureSupport                *ColorTemperatureSupport     `json:"_colorTemperatureSupport,omitempty"`
+	CompositeShootingOutputInterval        *int                         `json:"_compositeShootingOutputInterval,omitempty"`
+	CompositeShootingOutputIntervalSupport *RangeSupport                `json:"_compositeShootingOutputIntervalSupport,omitempty"`
+	CompositeShootingTime                  *int                         `json:"_compositeShootingTime,omitempty"`
+	CompositeShootingTimeSupport           *RangeSupport                `json:"_compositeShootingTimeSupport,omitempty"`
+	DateTimeZone                           *string                      `json:"dateTimeZone,omitempty"`
+	ExposureCompensation                   *float64                     `json:"exposureCompensation,omitempty"`
+	ExposureCompensationSupport            []float64                    `json:"exposureCompensationSupport,omitempty"`
+	ExposureDelay                          *int                         `json:"exposureDelay,omitempty"`
+	ExposureDelaySupport                   []int                        `json:"exposureDelaySupport,omitempty"`
+	ExposureProgram                        *int                         `json:"exposureProgram,omitempty"`
+	ExposureProgramSupport                 []int                        `json:"exposureProgramSupport,omitempty"`
+	FileFormat                             *FileFormat                  `json:"fileFormat,omitempty"`
+	FileFormatSupport                      []FileFormat                 `json:"fileFormatSupport,omitempty"`
+	Filter                                 *string                      `json:"_filter,omitempty"`
+	FilterSupport                          []string                     `json:"_filterSupport,omitempty"`
+	GPSInfo                                *GPSInfo                     `json:"gpsInfo,omitempty"`
+	GPSTagRecording                        *string                      `json:"_gpsTagRecording,omitempty"`
+	GPSTagRecordingSupport                 []string                     `json:"_gpsTagRecordingSupport,omitempty"`
+	ImageStitching                         *string                      `json:"_imageStitching,omitempty"`
+	ImageStitchingSupport                  []string                     `json:"_imageStitchingSupport,omitempty"`
+	ISO                                    *int                         `json:"iso,omitempty"`
+	ISOSupport                             []int                        `json:"isoSupport,omitempty"`
+	ISOAutoHighLimit                       *int                         `json:"isoAutoHighLimit,omitempty"`
+	ISOAutoHighLimitSupport                []int                        `json:"isoAutoHighLimitSupport,omitempty"`
+	Language                               *string                      `json:"_language,omitempty"`
+	LanguageSupport                        []string                     `json:"_languageSupport,omitempty"`
+	MaxRecordableTime                      *int                         `json:"_maxRecordableTime,omitempty"`
+	MaxRecordableTimeSupport               []int                        `json:"_maxRecordableTimeSupport,omitempty"`
+	Microphone                             *string                      `json:"_microphone,omitempty"`
+	MicrophoneSupport                      []string                     `json:"_microphoneSupport,omitempty"`
+	MicrophoneChannel                      *string                      `json:"_microphoneChannel,omitempty"`
+	MicrophoneChannelSupport               []string                     `json:"_microphoneChannelSupport,omitempty"`
+	OffDelay                               *int                         `json:"offDelay,omitempty"`
+	OffDelaySupport                        []int                        `json:"offDelaySupport,omitempty"`
+	PreviewFormat                          *PreviewFormat               `json:"previewFormat,omitempty"`
+	PreviewFormatSupport                   []PreviewFormat              `json:"previewFormatSupport,omitempty"`
+	RemainingPictures                      *int                         `json:"remainingPictures,omitempty"`
+	RemainingSpace                         *int64                       `json:"remainingSpace,omitempty"`
+	RemainingVideoSeconds                  *int                         `json:"_remainingVideoSeconds,omitempty"`
+	ShootingMethod                         *string                      `json:"_shootingMethod,omitempty"`
+	ShootingMethodSupport                  []string                     `json:"_shootingMethodSupport,omitempty"`
+	ShutterSpeed                           *float64                     `json:"shutterSpeed,omitempty"`
+	ShutterSpeedSupport                    []float64                    `json:"shutterSpeedSupport,omitempty"`
+	ShutterVolume                          *int                         `json:"_shutterVolume,omitempty"`
+	ShutterVolumeSupport                   *ShutterVolumeSupport        `json:"_shutterVolumeSupport,omitempty"`
+	SleepDelay                             *int                         `json:"sleepDelay,omitempty"`
+	SleepDelaySupport                      []int                        `json:"sleepDelaySupport,omitempty"`
+	TopBottomCorrection                    *string                      `json:"_topBottomCorrection,omitempty"`
+	TopBottomCorrectionSupport             []string                     `json:"_topBottomCorrectionSupport,omitempty"`
+	TopBottomCorrectionRotation            *TopBottomCorrectionRotation `json:"_topBottomCorrectionRotation,omitempty"`
+	TotalSpace                             *int64                       `json:"totalSpace,omitempty"`
+	VideoStitching                         *string                      `json:"_videoStitching,omitempty"`
+	VideoStitchingSupport                  []string                     `json:"_videoStitchingSupport,omitempty"`
+	VisibilityReduction                    *string                      `json:"_visibilityReduction,omitempty"`
+	VisibilityReductionSupport             []string                     `json:"_visibilityReductionSupport,omitempty"`
+	WhiteBalance                           *string                      `json:"whiteBalance,omitempty"`
+	WhiteBalanceSupport                    []string                     `json:"whiteBalanceSupport,omitempty"`
+	WhiteBalanceAutoStrength               *string                      `json:"_whiteBalanceAutoStrength,omitempty"`
+	WhiteBalanceAutoStrengthSupport        []string                     `json:"_whiteBalanceAutoStrengthSupport,omitempty"`
+	WLANChannel                            *int                         `json:"_wlanChannel,omitempty"`
+	WLANChannelSupport                     []int                        `json:"_wlanChannelSupport,omitempty"`
+
+	// Deprecated in Theta API v2.1 (OSC v2.0).
+	CaptureIntervalv20        *int             `json:"_captureInterval,omitempty"`
+	CaptureIntervalSupportv20 *IntervalSupport `json:"_captureIntervalSupport,omitempty"`
+	CaptureNumberv20          *int             `json:"_captureNumber,omitempty"`
+	CaptureNumberSupportv20   *NumberSupport   `json:"_captureNumberSupport,omitempty"`
+	HDMIReso                  *string          `json:"_HDMIreso,omitempty"`
+	HDMIResoSupport           []string         `json:"_HDMIresoSupport,omitempty"`
+}
+
+func (o Options) String() string {
+	return Stringify(o)
+}
+
+// IntervalSupport represents the range of the capture interval in seconds.
+type IntervalSupport struct {
+	MinInterval int `json:"minInterval"`
+	MaxInterval int `json:"maxInterval"`
+}
+
+// NumberSupport represents the range of the number of shots. A MaxNumber of 0
+// means no limit.
+type NumberSupport struct {
+	MinNumber int `json:"minNumber"`
+	MaxNumber int `json:"maxNumber"`
+}
+
+// ColorTemperatureSupport represents the range of the color temperature in
+// Kelvin.
+type ColorTemperatureSupport struct {
+	MinTemperature int `json:"minTemperature"`
+	MaxTemperature int `json:"maxTemperature"`
+	StepSize       int `json:"stepSize"`
 }
 
-// Bracket represents an bracket parameters.
+// ShutterVolumeSupport represents the range of the shutter volume.
+type ShutterVolumeSupport struct {
+	MinShutterVolume int `json:"minShutterVolume"`
+	MaxShutterVolume int `json:"maxShutterVolume"`
+}
+
+// RangeSupport represents a range of supported values with a step.
+type RangeSupport struct {
+	Min      int `json:"min"`
+	Max      int `json:"max"`
+	StepSize int `json:"stepSize"`
+}
+
+// FileFormat represents the format of a still image or video file.
+type FileFormat struct {
+	Type   string `json:"type"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Codec  string `json:"_codec,omitempty"`
+}
+
+// PreviewFormat represents the format of the live preview.
+type PreviewFormat struct {
+	Width     int `json:"width"`
+	Height    int `json:"height"`
+	Framerate int `json:"framerate"`
+}
+
+// GPSInfo represents the position information assigned to shot images.
+// A Lat and Lng of 65535 means that no position information is assigned.
+type GPSInfo struct {
+	Lat          float64 `json:"lat"`
+	Lng          float64 `json:"lng"`
+	Altitude     float64 `json:"_altitude"`
+	DateTimeZone string  `json:"_dateTimeZone"`
+	DatumType    string  `json:"_datumType"`
+}
+
+// TopBottomCorrectionRotation represents the rotation used by manual top/bottom
+// correction in degrees.
+type TopBottomCorrectionRotation struct {
+	Pitch float64 `json:"pitch"`
+	Roll  float64 `json:"roll"`
+	Yaw   float64 `json:"yaw"`
+}
+
+// MaxBracketNumber is the largest number of shots of an auto bracket.
+const MaxBracketNumber = 13
+
+// Bracket represents the parameters of an auto bracket, which takes a shot with
+// each of BracketParameters.
 type Bracket struct {
-	BracketNumber     int `json:"_bracketNumber,omitempty"`
-	BracketParameters struct {
-		ShutterSpeed     float64 `json:"shutterSpeed,omitempty"`
-		ISO              int     `json:"iso,omitempty"`
-		ColorTemperature int     `json:"_colorTemperature,omitempty"`
-	} `json:"_bracketParameters,omitempty"`
+	BracketNumber     int                `json:"_bracketNumber"`
+	BracketParameters []BracketParameter `json:"_bracketParameters"`
 }
 
-// SetOptions sets options to the Theta.
+// BracketParameter represents the exposure of a shot of an auto bracket.
+type BracketParameter struct {
+	ShutterSpeed     float64 `json:"shutterSpeed"`
+	ISO              int     `json:"iso"`
+	ColorTemperature int     `json:"_colorTemperature"`
+
+	// Aperture is supported by THETA Z1 only.
+	Aperture *float64 `json:"aperture,omitempty"`
+}
+
+// NewBracket returns the auto bracket taking a shot with each of params.
+func NewBracket(params ...BracketParameter) *Bracket {
+	return &Bracket{
+		BracketNumber:     len(params),
+		BracketParameters: params,
+	}
+}
+
+// validate checks the number of shots of b, which must match its parameters.
+func (b *Bracket) validate() error {
+	if b.BracketNumber != len(b.BracketParameters) {
+		return fmt.Errorf("auto bracket has %d parameters for %d shots", len(b.BracketParameters), b.BracketNumber)
+	}
+	if b.BracketNumber < 2 || MaxBracketNumber < b.BracketNumber {
+		return fmt.Errorf("auto bracket takes %d shots, want 2 to %d", b.BracketNumber, MaxBracketNumber)
+	}
+	return nil
+}
+
+// SetOptions sets options to the Theta. If Client.SupportedOptions is set, the
+// options are validated against it first and nothing is sent when they are
+// not supported.
 func (s *CommandServices) SetOptions(ctx context.Context, options *Options) (*CommandResponse, *http.Response, error) {
-	parameters := Parameters{Options: options}
-	fmt.Printf("APILevel: %d\n", s.client.apiLevel)
-	if s.client.apiLevel == 1 {
-		parameters.SessionID = &s.client.sessionID
+	if s.client.SupportedOptions != nil {
+		if err := options.Validate(s.client.SupportedOptions); err != nil {
+			return nil, nil, err
+		}
 	}
-	fmt.Printf("sessionID: %s\n", *parameters.SessionID)
 	body := CommandRequest{
-		Name:       String("camera.setOptions"),
-		Parameters: &parameters,
+		Name: String("camera.setOptions"),
+		Parameters: &Parameters{
+			Options:   options,
+			SessionID: s.client.session(),
+		},
 	}
 	return s.commandsExecute(ctx, body)
 }
+
+// GetOptions gets the current values of the options named by names, such as
+// OptionAperture, and the values they support, such as OptionApertureSupport.
+func (s *CommandServices) GetOptions(ctx context.Context, names ...string) (*Options, *http.Response, error) {
+	body := CommandRequest{
+		Name: String("camera.getOptions"),
+		Parameters: &Parameters{
+			OptionNames: names,
+			SessionID:   s.client.session(),
+		},
+	}
+	cmd, resp, err := s.commandsExecute(ctx, body)
+	if err != nil {
+		return nil, resp, err
+	}
+	if cmd.Results == nil || cmd.Results.Options == nil {
+		return nil, resp, errors.New("getOptions returned no options")
+	}
+	return cmd.Results.Options, resp, nil
+}
+
+// OptionError reports an option value which is not in the values supported by
+// the camera.
+type OptionError struct {
+	Name    string      // name of the option, such as OptionISO
+	Value   interface{} // requested value
+	Support interface{} // supported values reported by the camera
+}
+
+func (e *OptionError) Error() string {
+	return fmt.Sprintf("option %v: value %v is not supported (supported: %v)",
+		e.Name, stringifyOption(e.Value), stringifyOption(e.Support))
+}
+
+func stringifyOption(v interface{}) string {
+	if s, ok := v.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return Stringify(v)
+}
+
+// OptionsError reports every unsupported value found by Options.Validate.
+type OptionsError []*OptionError
+
+func (e OptionsError) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Validate checks each value set in o against the corresponding Support values
+// in support, which is usually the result of GetOptions. Options whose Support
+// values are not present in support, or all of them if support is nil, are not
+// checked. The returned error is an OptionsError if any value is not supported.
+func (o *Options) Validate(support *Options) error {
+	if support == nil {
+		return nil
+	}
+	var errs OptionsError
+	check := func(ok bool, name string, value, values interface{}) {
+		if !ok {
+			errs = append(errs, &OptionError{Name: name, Value: value, Support: values})
+		}
+	}
+
+	if o.Aperture != nil && support.ApertureSupport != nil {
+		check(containsFloat(support.ApertureSupport, *o.Aperture), OptionAperture, *o.Aperture, support.ApertureSupport)
+	}
+	if o.AutoBracket != nil {
+		n := o.AutoBracket.BracketNumber
+		if support.AutoBracketSupport != nil {
+			check(containsInt(support.AutoBracketSupport, n), OptionAutoBracket, n, support.AutoBracketSupport)
+		}
+		for _, p := range o.AutoBracket.BracketParameters {
+			if support.ShutterSpeedSupport != nil {
+				check(containsFloat(support.ShutterSpeedSupport, p.ShutterSpeed), OptionAutoBracket, p.ShutterSpeed, support.ShutterSpeedSupport)
+			}
+			if support.ISOSupport != nil {
+				check(containsInt(support.ISOSupport, p.ISO), OptionAutoBracket, p.ISO, support.ISOSupport)
+			}
+			if support.ColorTemperatureSupport != nil {
+				check(support.ColorTemperatureSupport.contains(p.ColorTemperature), OptionAutoBracket, p.ColorTemperature, support.ColorTemperatureSupport)
+			}
+		}
+	}
+	if o.Bitrate != nil && support.BitrateSupport != nil {
+		check(containsString(support.BitrateSupport, *o.Bitrate), OptionBitrate, *o.Bitrate, support.BitrateSupport)
+	}
+	if o.CaptureInterval != nil && support.CaptureIntervalSupport != nil {
+		check(support.CaptureIntervalSupport.contains(*o.CaptureInterval), OptionCaptureInterval, *o.CaptureInterval, support.CaptureIntervalSupport)
+	}
+	if o.CaptureIntervalv20 != nil && support.CaptureIntervalSupportv20 != nil {
+		check(support.CaptureIntervalSupportv20.contains(*o.CaptureIntervalv20), OptionCaptureIntervalv20, *o.CaptureIntervalv20, support.CaptureIntervalSupportv20)
+	}
+	if o.CaptureMode != nil && support.CaptureModeSupport != nil {
+		check(containsString(support.CaptureModeSupport, *o.CaptureMode), OptionCaptureMode, *o.CaptureMode, support.CaptureModeSupport)
+	}
+	if o.CaptureNumber != nil && support.CaptureNumberSupport != nil {
+		check(support.CaptureNumberSupport.contains(*o.CaptureNumber), OptionCaptureNumber, *o.CaptureNumber, support.CaptureNumberSupport)
+	}
+	if o.CaptureNumberv20 != nil && support.CaptureNumberSupportv20 != nil {
+		check(support.CaptureNumberSupportv20.contains(*o.CaptureNumberv20), OptionCaptureNumberv20, *o.CaptureNumberv20, support.CaptureNumberSupportv20)
+	}
+	if o.ColorTemperature != nil && support.ColorTemperatureSupport != nil {
+		check(support.ColorTemperatureSupport.contains(*o.ColorTemperature), OptionColorTemperature, *o.ColorTemperature, support.ColorTemperatureSupport)
+	}
+	if o.CompositeShootingOutputInterval != nil && support.CompositeShootingOutputIntervalSupport != nil {
+		check(support.CompositeShootingOutputIntervalSupport.contains(*o.CompositeShootingOutputInterval), OptionCompositeShootingOutputInterval, *o.CompositeShootingOutputInterval, support.CompositeShootingOutputIntervalSupport)
+	}
+	if o.CompositeShootingTime != nil && support.CompositeShootingTimeSupport != nil {
+		check(support.CompositeShootingTimeSupport.contains(*o.CompositeShootingTime), OptionCompositeShootingTime, *o.CompositeShootingTime, support.CompositeShootingTimeSupport)
+	}
+	if o.ExposureCompensation != nil && support.ExposureCompensationSupport != nil {
+		check(containsFloat(support.ExposureCompensationSupport, *o.ExposureCompensation), OptionExposureCompensation, *o.ExposureCompensation, support.ExposureCompensationSupport)
+	}
+	if o.ExposureDelay != nil && support.ExposureDelaySupport != nil {
+		check(containsInt(support.ExposureDelaySupport, *o.ExposureDelay), OptionExposureDelay, *o.ExposureDelay, support.ExposureDelaySupport)
+	}
+	if o.ExposureProgram != nil && support.ExposureProgramSupport != nil {
+		check(containsInt(support.ExposureProgramSupport, *o.ExposureProgram), OptionExposureProgram, *o.ExposureProgram, support.ExposureProgramSupport)
+	}
+	if o.FileFormat != nil && support.FileFormatSupport != nil {
+		ok := false
+		for _, f := range support.FileFormatSupport {
+			ok = ok || f == *o.FileFormat
+		}
+		check(ok, OptionFileFormat, *o.FileFormat, support.FileFormatSupport)
+	}
+	if o.Filter != nil && support.FilterSupport != nil {
+		check(containsString(support.FilterSupport, *o.Filter), OptionFilter, *o.Filter, support.FilterSupport)
+	}
+	if o.ImageStitching != nil && support.ImageStitchingSupport != nil {
+		check(containsString(support.ImageStitchingSupport, *o.ImageStitching), OptionImageStitching, *o.ImageStitching, support.ImageStitchingSupport)
+	}
+	if o.ISO != nil && support.ISOSupport != nil {
+		check(containsInt(support.ISOSupport, *o.ISO), OptionISO, *o.ISO, support.ISOSupport)
+	}
+	if o.ISOAutoHighLimit != nil && support.ISOAutoHighLimitSupport != nil {
+		check(containsInt(support.ISOAutoHighLimitSupport, *o.ISOAutoHighLimit), OptionISOAutoHighLimit, *o.ISOAutoHighLimit, support.ISOAutoHighLimitSupport)
+	}
+	if o.Language != nil && support.LanguageSupport != nil {
+		check(containsString(support.LanguageSupport, *o.Language), OptionLanguage, *o.Language, support.LanguageSupport)
+	}
+	if o.OffDelay != nil && support.OffDelaySupport != nil {
+		check(containsInt(support.OffDelaySupport, *o.OffDelay), OptionOffDelay, *o.OffDelay, support.OffDelaySupport)
+	}
+	if o.PreviewFormat != nil && support.PreviewFormatSupport != nil {
+		ok := false
+		for _, f := range support.PreviewFormatSupport {
+			ok = ok || f == *o.PreviewFormat
+		}
+		check(ok, OptionPreviewFormat, *o.PreviewFormat, support.PreviewFormatSupport)
+	}
+	if o.ShutterSpeed != nil && support.ShutterSpeedSupport != nil {
+		check(containsFloat(support.ShutterSpeedSupport, *o.ShutterSpeed), OptionShutterSpeed, *o.ShutterSpeed, support.ShutterSpeedSupport)
+	}
+	if o.ShutterVolume != nil && support.ShutterVolumeSupport != nil {
+		v, r := *o.ShutterVolume, support.ShutterVolumeSupport
+		check(r.MinShutterVolume <= v && v <= r.MaxShutterVolume, OptionShutterVolume, v, r)
+	}
+	if o.SleepDelay != nil && support.SleepDelaySupport != nil {
+		check(containsInt(support.SleepDelaySupport, *o.SleepDelay), OptionSleepDelay, *o.SleepDelay, support.SleepDelaySupport)
+	}
+	if o.WhiteBalance != nil && support.WhiteBalanceSupport != nil {
+		check(containsString(support.WhiteBalanceSupport, *o.WhiteBalance), OptionWhiteBalance, *o.WhiteBalance, support.WhiteBalanceSupport)
+	}
+	if o.WLANChannel != nil && support.WLANChannelSupport != nil {
+		check(containsInt(support.WLANChannelSupport, *o.WLANChannel), OptionWLANChannel, *o.WLANChannel, support.WLANChannelSupport)
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (r *IntervalSupport) contains(v int) bool {
+	return r.MinInterval <= v && v <= r.MaxInterval
+}
+
+func (r *NumberSupport) contains(v int) bool {
+	// A number of 0 means shooting until stopped, which is always allowed.
+	return v == 0 || r.MinNumber <= v && (r.MaxNumber == 0 || v <= r.MaxNumber)
+}
+
+func (r *ColorTemperatureSupport) contains(v int) bool {
+	if v < r.MinTemperature || r.MaxTemperature < v {
+		return false
+	}
+	return r.StepSize <= 0 || (v-r.MinTemperature)%r.StepSize == 0
+}
+
+func (r *RangeSupport) contains(v int) bool {
+	if v < r.Min || r.Max < v {
+		return false
+	}
+	return r.StepSize <= 0 || (v-r.Min)%r.StepSize == 0
+}
+
+func containsInt(values []int, v int) bool {
+	for _, x := range values {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFloat(values []float64, v float64) bool {
+	for _, x := range values {
+		if math.Abs(x-v) <= 1e-9*math.Max(math.Abs(x), math.Abs(v)) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, v string) bool {
+	for _, x := range values {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
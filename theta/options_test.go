@@ -0,0 +1,184 @@
+// Copyright (c) 2017 "Shun Yokota" All rights reserved
+//
+// Part of the source code is adapted from https://github.com/google/go-github
+// Copyright 2013 The go-github AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package theta
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestCommandServices_GetOptions(t *testing.T) {
+	setup()
+	defer teardown()
+	client.apiLevel = 2
+
+	mux.HandleFunc(commandsExecuteURL, func(w http.ResponseWriter, r *http.Request) {
+		v := new(CommandRequest)
+		json.NewDecoder(r.Body).Decode(v)
+		if got, want := *v.Name, "camera.getOptions"; got != want {
+			t.Errorf("Request name is %v, want %v", got, want)
+		}
+		want := []string{OptionClientVersion, OptionAutoBracketSupport}
+		if got := v.Parameters.OptionNames; !reflect.DeepEqual(got, want) {
+			t.Errorf("Request optionNames is %v, want %v", got, want)
+		}
+		fmt.Fprint(w, `{"name":"camera.getOptions","state":"done",
+			"results":{"options":{"clientVersion":2,"_autoBracketSupport":[2,3,5,7]}}}`)
+	})
+
+	opts, _, err := client.Command.GetOptions(context.Background(), OptionClientVersion, OptionAutoBracketSupport)
+	if err != nil {
+		t.Fatalf("Command.GetOptions returned error: %v", err)
+	}
+	want := &Options{ClientVersion: Int(2), AutoBracketSupport: []int{2, 3, 5, 7}}
+	if !reflect.DeepEqual(opts, want) {
+		t.Errorf("Command.GetOptions returned %v, want %v", opts, want)
+	}
+}
+
+func TestOptions_unmarshal(t *testing.T) {
+	data := `{
+		"aperture":2.0,"apertureSupport":[2.0],
+		"captureInterval":8,"captureIntervalSupport":{"minInterval":8,"maxInterval":3600},
+		"captureNumber":0,"captureNumberSupport":{"minNumber":2,"maxNumber":9999},
+		"_colorTemperature":5100,"_colorTemperatureSupport":{"minTemperature":2500,"maxTemperature":10000,"stepSize":100},
+		"fileFormat":{"type":"jpeg","width":5376,"height":2688},
+		"fileFormatSupport":[{"type":"jpeg","width":5376,"height":2688},{"type":"mp4","width":3840,"height":1920,"_codec":"H.264/MPEG-4 AVC"}],
+		"gpsInfo":{"lat":35.671344,"lng":139.765129,"_altitude":35.2,"_dateTimeZone":"2017:01:04 11:23:54+09:00","_datumType":"WGS84"},
+		"previewFormat":{"width":1024,"height":512,"framerate":30},
+		"previewFormatSupport":[{"width":1024,"height":512,"framerate":30},{"width":640,"height":320,"framerate":10}],
+		"_shutterVolumeSupport":{"minShutterVolume":0,"maxShutterVolume":100},
+		"_topBottomCorrectionRotation":{"pitch":0,"roll":0,"yaw":90},
+		"remainingSpace":25769803776,
+		"_captureInterval":5,"_captureIntervalSupport":{"minInterval":5,"maxInterval":3600},
+		"_captureNumber":10,"_captureNumberSupport":{"minNumber":2,"maxNumber":9999}
+	}`
+	got := new(Options)
+	if err := json.Unmarshal([]byte(data), got); err != nil {
+		t.Fatalf("json.Unmarshal returned error: %v", err)
+	}
+	space := int64(25769803776)
+	want := &Options{
+		Aperture:                Float64(2.0),
+		ApertureSupport:         []float64{2.0},
+		CaptureInterval:         Int(8),
+		CaptureIntervalSupport:  &IntervalSupport{MinInterval: 8, MaxInterval: 3600},
+		CaptureNumber:           Int(0),
+		CaptureNumberSupport:    &NumberSupport{MinNumber: 2, MaxNumber: 9999},
+		ColorTemperature:        Int(5100),
+		ColorTemperatureSupport: &ColorTemperatureSupport{MinTemperature: 2500, MaxTemperature: 10000, StepSize: 100},
+		FileFormat:              &FileFormat{Type: "jpeg", Width: 5376, Height: 2688},
+		FileFormatSupport: []FileFormat{
+			{Type: "jpeg", Width: 5376, Height: 2688},
+			{Type: "mp4", Width: 3840, Height: 1920, Codec: "H.264/MPEG-4 AVC"},
+		},
+		GPSInfo: &GPSInfo{
+			Lat:          35.671344,
+			Lng:          139.765129,
+			Altitude:     35.2,
+			DateTimeZone: "2017:01:04 11:23:54+09:00",
+			DatumType:    "WGS84",
+		},
+		PreviewFormat: &PreviewFormat{Width: 1024, Height: 512, Framerate: 30},
+		PreviewFormatSupport: []PreviewFormat{
+			{Width: 1024, Height: 512, Framerate: 30},
+			{Width: 640, Height: 320, Framerate: 10},
+		},
+		ShutterVolumeSupport:        &ShutterVolumeSupport{MinShutterVolume: 0, MaxShutterVolume: 100},
+		TopBottomCorrectionRotation: &TopBottomCorrectionRotation{Yaw: 90},
+		RemainingSpace:              &space,
+		CaptureIntervalv20:          Int(5),
+		CaptureIntervalSupportv20:   &IntervalSupport{MinInterval: 5, MaxInterval: 3600},
+		CaptureNumberv20:            Int(10),
+		CaptureNumberSupportv20:     &NumberSupport{MinNumber: 2, MaxNumber: 9999},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("json.Unmarshal returned %v, want %v", got, want)
+	}
+}
+
+func TestOptions_marshal(t *testing.T) {
+	o := &Options{
+		Aperture:           Float64(5.6),
+		CaptureIntervalv20: Int(5),
+		GPSInfo:            &GPSInfo{Lat: 65535, Lng: 65535, DatumType: "WGS84"},
+	}
+	got, err := json.Marshal(o)
+	if err != nil {
+		t.Fatalf("json.Marshal returned error: %v", err)
+	}
+	want := `{"aperture":5.6,"gpsInfo":{"lat":65535,"lng":65535,"_altitude":0,"_dateTimeZone":"","_datumType":"WGS84"},"_captureInterval":5}`
+	if string(got) != want {
+		t.Errorf("json.Marshal returned %s, want %s", got, want)
+	}
+}
+
+func TestOptions_Validate(t *testing.T) {
+	support := &Options{
+		ApertureSupport:        []float64{2.0},
+		AutoBracketSupport:     []int{3, 5},
+		CaptureIntervalSupport: &IntervalSupport{MinInterval: 8, MaxInterval: 3600},
+		ISOSupport:             []int{0, 100, 200, 400},
+		ShutterSpeedSupport:    []float64{0.00015625, 0.0002, 1.0 / 60},
+	}
+
+	ok := &Options{
+		Aperture:        Float64(2.0),
+		ISO:             Int(200),
+		ShutterSpeed:    Float64(1.0 / 60),
+		CaptureInterval: Int(10),
+		WhiteBalance:    String("daylight"), // not checked without whiteBalanceSupport
+	}
+	if err := ok.Validate(support); err != nil {
+		t.Errorf("Options.Validate returned error: %v", err)
+	}
+
+	if err := ok.Validate(nil); err != nil {
+		t.Errorf("Options.Validate with nil support returned error: %v", err)
+	}
+
+	bad := &Options{
+		ISO:             Int(125),
+		CaptureInterval: Int(4),
+		AutoBracket:     &Bracket{BracketNumber: 7},
+	}
+	err := bad.Validate(support)
+	errs, isOptionsError := err.(OptionsError)
+	if !isOptionsError {
+		t.Fatalf("Options.Validate returned %#v, want OptionsError", err)
+	}
+	var names []string
+	for _, e := range errs {
+		names = append(names, e.Name)
+	}
+	want := []string{OptionAutoBracket, OptionCaptureInterval, OptionISO}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("Options.Validate reported %v, want %v", names, want)
+	}
+}
+
+func TestCommandServices_SetOptions_unsupported(t *testing.T) {
+	setup()
+	defer teardown()
+	client.apiLevel = 2
+	client.SupportedOptions = &Options{ISOSupport: []int{100, 200}}
+
+	mux.HandleFunc(commandsExecuteURL, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("SetOptions sent a request with unsupported options")
+	})
+
+	_, _, err := client.Command.SetOptions(context.Background(), &Options{ISO: Int(125)})
+	if _, ok := err.(OptionsError); !ok {
+		t.Errorf("Command.SetOptions returned %#v, want OptionsError", err)
+	}
+}
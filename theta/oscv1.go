@@ -11,12 +11,124 @@ package theta
 import (
 	"context"
 	"net/http"
+	"time"
 )
 
 // oscv1.go is descrived methods deprecated in Theta API v2.1(oscV2.0).
 
+// defaultSessionTimeout is the session timeout of Theta API v2.0 used until the
+// camera reports one.
+const defaultSessionTimeout = 180 * time.Second
+
 // StartSession begins the session. Issued the session ID. StartSession is deprecated
 // in Theta API v2.1 (OSC v2.0).
 func (s *CommandServices) StartSession(ctx context.Context) (*CommandResponse, *http.Response, error) {
-	return s.commandsExecute(ctx, CommandRequest{Name: String("camera.startSession")})
+	cmd, resp, err := s.commandsExecute(ctx, CommandRequest{Name: String("camera.startSession")})
+	if err != nil {
+		return cmd, resp, err
+	}
+	s.client.setSession(cmd.Results)
+	return cmd, resp, nil
+}
+
+// UpdateSession extends the timeout of the session. The camera may issue a new
+// session ID. UpdateSession is deprecated in Theta API v2.1 (OSC v2.0).
+func (s *CommandServices) UpdateSession(ctx context.Context) (*CommandResponse, *http.Response, error) {
+	body := CommandRequest{
+		Name:       String("camera.updateSession"),
+		Parameters: &Parameters{SessionID: String(s.client.currentSessionID())},
+	}
+	cmd, resp, err := s.commandsExecute(ctx, body)
+	if err != nil {
+		return cmd, resp, err
+	}
+	s.client.setSession(cmd.Results)
+	return cmd, resp, nil
+}
+
+// CloseSession ends the session. CloseSession is deprecated in Theta API v2.1
+// (OSC v2.0).
+func (s *CommandServices) CloseSession(ctx context.Context) (*CommandResponse, *http.Response, error) {
+	body := CommandRequest{
+		Name:       String("camera.closeSession"),
+		Parameters: &Parameters{SessionID: String(s.client.currentSessionID())},
+	}
+	cmd, resp, err := s.commandsExecute(ctx, body)
+	if err != nil {
+		return cmd, resp, err
+	}
+	s.client.setSession(nil)
+	return cmd, resp, nil
+}
+
+// KeepSessionAlive renews the session with UpdateSession before it times out,
+// and starts a new session if the camera no longer knows the current one. It
+// blocks until ctx is done and returns ctx.Err(), so it is usually run in its
+// own goroutine. Failed renewals are retried sooner. Sessions only exist in
+// Theta API v2.0 (OSC v1.0), so KeepSessionAlive returns nil as soon as the
+// client uses another level, for example after Begin has upgraded it to v2.1.
+func (c *Client) KeepSessionAlive(ctx context.Context) error {
+	for {
+		if c.APILevel() != 1 {
+			return nil
+		}
+		c.mu.Lock()
+		timeout := c.sessionTimeout
+		c.mu.Unlock()
+		if timeout <= 0 {
+			timeout = defaultSessionTimeout
+		}
+
+		t := time.NewTimer(timeout * 2 / 3)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+
+		// UpdateSession starts a new session by itself if the camera rejects
+		// the current one.
+		if c.APILevel() != 1 {
+			return nil
+		}
+		_, _, err := c.Command.UpdateSession(ctx)
+		for err != nil && ctx.Err() == nil {
+			t := time.NewTimer(timeout / 6)
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return ctx.Err()
+			case <-t.C:
+			}
+			if c.APILevel() != 1 {
+				return nil
+			}
+			_, _, err = c.Command.UpdateSession(ctx)
+		}
+	}
+}
+
+// setSession stores the session ID and timeout issued by the camera. A nil r
+// clears the session.
+func (c *Client) setSession(r *Results) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if r == nil {
+		c.sessionID = ""
+		c.sessionTimeout = 0
+		return
+	}
+	if r.SessionID != nil {
+		c.sessionID = *r.SessionID
+	}
+	if r.Timeout != nil {
+		c.sessionTimeout = time.Duration(*r.Timeout) * time.Second
+	}
+}
+
+func (c *Client) currentSessionID() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.sessionID
 }
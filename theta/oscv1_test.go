@@ -0,0 +1,114 @@
+// Copyright (c) 2017 "Shun Yokota" All rights reserved
+//
+// Part of the source code is adapted from https://github.com/google/go-github
+// Copyright 2013 The go-github AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package theta
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCommandServices_StartSession(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc(commandsExecuteURL, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"name":"camera.startSession","state":"done","results":{"sessionId":"SID_0001","timeout":180}}`)
+	})
+
+	if _, _, err := client.Command.StartSession(context.Background()); err != nil {
+		t.Fatalf("Command.StartSession returned error: %v", err)
+	}
+	if got, want := client.sessionID, "SID_0001"; got != want {
+		t.Errorf("Command.StartSession set sessionID to %v, want %v", got, want)
+	}
+	if got, want := client.sessionTimeout, 180*time.Second; got != want {
+		t.Errorf("Command.StartSession set sessionTimeout to %v, want %v", got, want)
+	}
+}
+
+func TestCommandServices_invalidSessionID(t *testing.T) {
+	setup()
+	defer teardown()
+	client.sessionID = "SID_0001"
+
+	var names []string
+	mux.HandleFunc(commandsExecuteURL, func(w http.ResponseWriter, r *http.Request) {
+		v := new(CommandRequest)
+		json.NewDecoder(r.Body).Decode(v)
+		names = append(names, *v.Name)
+		switch {
+		case *v.Name == "camera.startSession":
+			fmt.Fprint(w, `{"name":"camera.startSession","state":"done","results":{"sessionId":"SID_0002","timeout":180}}`)
+		case *v.Parameters.SessionID == "SID_0001":
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"name":"camera.takePicture","state":"error","error":{"code":"invalidSessionId","message":"timed out"}}`)
+		default:
+			fmt.Fprint(w, `{"name":"camera.takePicture","state":"done","results":{"fileUri":"100RICOH/R0010001.JPG"}}`)
+		}
+	})
+
+	if _, _, err := client.Command.TakePicture(context.Background()); err != nil {
+		t.Fatalf("Command.TakePicture returned error: %v", err)
+	}
+	want := []string{"camera.takePicture", "camera.startSession", "camera.takePicture"}
+	if fmt.Sprint(names) != fmt.Sprint(want) {
+		t.Errorf("Commands sent are %v, want %v", names, want)
+	}
+	if got, want := client.sessionID, "SID_0002"; got != want {
+		t.Errorf("sessionID is %v, want %v", got, want)
+	}
+}
+
+func TestClient_KeepSessionAlive(t *testing.T) {
+	setup()
+	defer teardown()
+	client.sessionID = "SID_0001"
+	client.sessionTimeout = 30 * time.Millisecond
+
+	updated := make(chan string, 1)
+	mux.HandleFunc(commandsExecuteURL, func(w http.ResponseWriter, r *http.Request) {
+		v := new(CommandRequest)
+		json.NewDecoder(r.Body).Decode(v)
+		select {
+		case updated <- *v.Name:
+		default:
+		}
+		fmt.Fprint(w, `{"name":"camera.updateSession","state":"done","results":{"sessionId":"SID_0001","timeout":180}}`)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- client.KeepSessionAlive(ctx) }()
+
+	if got, want := <-updated, "camera.updateSession"; got != want {
+		t.Errorf("KeepSessionAlive sent %v, want %v", got, want)
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("KeepSessionAlive returned %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestClient_KeepSessionAlive_v21(t *testing.T) {
+	setup()
+	defer teardown()
+	client.apiLevel = 2
+
+	mux.HandleFunc(commandsExecuteURL, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("KeepSessionAlive sent a command in v2.1")
+	})
+
+	if err := client.KeepSessionAlive(context.Background()); err != nil {
+		t.Errorf("KeepSessionAlive returned %v, want nil", err)
+	}
+}
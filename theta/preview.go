@@ -0,0 +1,263 @@
+// Copyright (c) 2017 "Shun Yokota" All rights reserved
+//
+// Part of the source code is adapted from https://github.com/google/go-github
+// Copyright 2013 The go-github AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package theta
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/jpeg"
+	"io"
+	"net/http"
+)
+
+// maxFrameSize is the largest JPEG frame FrameReader accepts. Larger data is
+// treated as a broken frame.
+const maxFrameSize = 16 << 20
+
+// JPEG markers used to find frames in a stream.
+const (
+	markerSOI  = 0xd8 // start of image
+	markerEOI  = 0xd9 // end of image
+	markerSOS  = 0xda // start of scan
+	markerTEM  = 0x01
+	markerRST0 = 0xd0
+	markerRST7 = 0xd7
+)
+
+var (
+	errBrokenFrame = errors.New("broken JPEG frame")
+
+	// errNewFrame is returned when the SOI marker of the next frame is found
+	// within a frame, which has therefore been cut short.
+	errNewFrame = errors.New("JPEG frame interrupted by a new frame")
+)
+
+// Frame is a JPEG image of the live preview.
+type Frame []byte
+
+// Image decodes the frame.
+func (f Frame) Image() (image.Image, error) {
+	return jpeg.Decode(bytes.NewReader(f))
+}
+
+// FrameReader reads JPEG frames from a motion JPEG stream, such as the
+// multipart/x-mixed-replace stream of the live preview. Frames are found by
+// their JPEG markers rather than by the multipart boundaries, which some
+// firmware does not send consistently. Data between frames is skipped.
+type FrameReader struct {
+	r   *bufio.Reader
+	buf bytes.Buffer
+}
+
+// NewFrameReader returns a FrameReader reading from r.
+func NewFrameReader(r io.Reader) *FrameReader {
+	return &FrameReader{r: bufio.NewReaderSize(r, 64<<10)}
+}
+
+// ReadFrame reads the next frame. Broken frames are skipped. It returns io.EOF
+// when the stream ends.
+func (fr *FrameReader) ReadFrame() (Frame, error) {
+	soi := false // the SOI marker of the frame has been read
+	for {
+		if !soi {
+			if err := fr.skipToSOI(); err != nil {
+				return nil, err
+			}
+		}
+		fr.buf.Reset()
+		fr.buf.Write([]byte{0xff, markerSOI})
+		err := fr.readSegments()
+		// A frame cut short by the next one restarts from its SOI marker.
+		soi = err == errNewFrame
+		if err == errBrokenFrame || err == errNewFrame {
+			continue
+		}
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return nil, err
+		}
+		return Frame(append([]byte(nil), fr.buf.Bytes()...)), nil
+	}
+}
+
+// skipToSOI consumes the stream until just after the next SOI marker.
+func (fr *FrameReader) skipToSOI() error {
+	for {
+		b, err := fr.r.ReadByte()
+		if err != nil {
+			return err
+		}
+		if b != 0xff {
+			continue
+		}
+		next, err := fr.r.Peek(1)
+		if err != nil {
+			return err
+		}
+		if next[0] == markerSOI {
+			fr.r.ReadByte()
+			return nil
+		}
+	}
+}
+
+// readSegments reads the segments of a frame after its SOI marker up to and
+// including its EOI marker. It returns errNewFrame after consuming the SOI
+// marker of another frame.
+func (fr *FrameReader) readSegments() error {
+	m, err := fr.nextMarker()
+	for err == nil {
+		if fr.buf.Len() > maxFrameSize {
+			return errBrokenFrame
+		}
+		switch {
+		case m == markerEOI:
+			fr.buf.Write([]byte{0xff, m})
+			return nil
+		case m == markerSOI:
+			return errNewFrame
+		case m == markerTEM || markerRST0 <= m && m <= markerRST7:
+			fr.buf.Write([]byte{0xff, m})
+			m, err = fr.nextMarker()
+		default:
+			if err = fr.readSegment(m); err != nil {
+				return err
+			}
+			if m == markerSOS {
+				m, err = fr.readScan()
+			} else {
+				m, err = fr.nextMarker()
+			}
+		}
+	}
+	return err
+}
+
+// nextMarker reads the marker which must come next.
+func (fr *FrameReader) nextMarker() (byte, error) {
+	b, err := fr.r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	if b != 0xff {
+		return 0, errBrokenFrame
+	}
+	return fr.readMarker()
+}
+
+// readMarker reads a marker code after its 0xff, skipping fill bytes.
+func (fr *FrameReader) readMarker() (byte, error) {
+	for {
+		m, err := fr.r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if m != 0xff {
+			return m, nil
+		}
+	}
+}
+
+// readSegment reads the length and the data of the segment of marker m.
+func (fr *FrameReader) readSegment(m byte) error {
+	var length [2]byte
+	if _, err := io.ReadFull(fr.r, length[:]); err != nil {
+		return err
+	}
+	n := int(length[0])<<8 | int(length[1])
+	if n < 2 || fr.buf.Len()+n > maxFrameSize {
+		return errBrokenFrame
+	}
+	fr.buf.Write([]byte{0xff, m, length[0], length[1]})
+	_, err := io.CopyN(&fr.buf, fr.r, int64(n-2))
+	return err
+}
+
+// readScan reads entropy-coded data and returns the marker which ends it.
+func (fr *FrameReader) readScan() (byte, error) {
+	for {
+		if fr.buf.Len() > maxFrameSize {
+			return 0, errBrokenFrame
+		}
+		b, err := fr.r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if b != 0xff {
+			fr.buf.WriteByte(b)
+			continue
+		}
+		m, err := fr.readMarker()
+		if err != nil {
+			return 0, err
+		}
+		if m == 0x00 || markerRST0 <= m && m <= markerRST7 {
+			// A stuffed 0xff byte or a restart marker within the data.
+			fr.buf.Write([]byte{0xff, m})
+			continue
+		}
+		return m, nil
+	}
+}
+
+// PreviewStream is the live preview stream of the camera.
+type PreviewStream struct {
+	ctx  context.Context
+	body io.ReadCloser
+	fr   *FrameReader
+}
+
+// Next returns the next frame of the stream. It returns ctx.Err() once the
+// context passed to LivePreview is done.
+func (p *PreviewStream) Next() (Frame, error) {
+	f, err := p.fr.ReadFrame()
+	if err != nil && p.ctx.Err() != nil {
+		return nil, p.ctx.Err()
+	}
+	return f, err
+}
+
+// Close closes the stream.
+func (p *PreviewStream) Close() error {
+	return p.body.Close()
+}
+
+// LivePreview starts the live preview, which is a stream of equirectangular
+// JPEG frames. The stream is closed when ctx is done; it should also be closed
+// with Close when no longer used, since the camera serves only one live
+// preview at a time.
+func (s *CommandServices) LivePreview(ctx context.Context) (*PreviewStream, *http.Response, error) {
+	body := CommandRequest{Name: String("camera.getLivePreview")}
+	if id := s.client.session(); id != nil {
+		body = CommandRequest{
+			Name:       String("camera._getLivePreview"),
+			Parameters: &Parameters{SessionID: id},
+		}
+	}
+	req, err := s.client.NewRequest("POST", commandsExecuteURL, body)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Accept", "multipart/x-mixed-replace, image/jpeg")
+	resp, err := s.client.bareDo(ctx, req)
+	if err != nil {
+		return nil, resp, err
+	}
+	stream := &PreviewStream{
+		ctx:  ctx,
+		body: resp.Body,
+		fr:   NewFrameReader(resp.Body),
+	}
+	return stream, resp, nil
+}
@@ -0,0 +1,155 @@
+// Copyright (c) 2017 "Shun Yokota" All rights reserved
+//
+// Part of the source code is adapted from https://github.com/google/go-github
+// Copyright 2013 The go-github AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package theta
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"net/http"
+	"testing"
+)
+
+// testJPEG returns a JPEG image filled with c, with an APP1 segment holding a
+// thumbnail-like SOI/EOI pair as in EXIF.
+func testJPEG(t *testing.T, c color.Color) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, 16, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 16; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	app1 := []byte{0xff, 0xe1, 0x00, 0x08, 0xff, 0xd8, 0xff, 0xd9, 0x00, 0x00}
+	b := buf.Bytes()
+	return append(append(append([]byte(nil), b[:2]...), app1...), b[2:]...)
+}
+
+func TestFrameReader(t *testing.T) {
+	frames := [][]byte{
+		testJPEG(t, color.RGBA{255, 0, 0, 255}),
+		testJPEG(t, color.RGBA{0, 0, 255, 255}),
+	}
+	var stream bytes.Buffer
+	for i, f := range frames {
+		// inconsistent boundaries and headers
+		fmt.Fprintf(&stream, "--boundary%d\r\nContent-Type: image/jpeg\r\n\r\n", i)
+		stream.Write(f)
+		stream.WriteString("\r\n")
+	}
+
+	fr := NewFrameReader(&stream)
+	for i, want := range frames {
+		got, err := fr.ReadFrame()
+		if err != nil {
+			t.Fatalf("ReadFrame %d returned error: %v", i, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("ReadFrame %d returned %d bytes, want %d", i, len(got), len(want))
+		}
+		if _, err := got.Image(); err != nil {
+			t.Errorf("Frame.Image %d returned error: %v", i, err)
+		}
+	}
+	if _, err := fr.ReadFrame(); err != io.EOF {
+		t.Errorf("ReadFrame at the end returned %v, want %v", err, io.EOF)
+	}
+}
+
+func TestFrameReader_truncated(t *testing.T) {
+	broken := testJPEG(t, color.RGBA{0, 255, 0, 255})
+	frames := [][]byte{
+		testJPEG(t, color.RGBA{255, 0, 0, 255}),
+		testJPEG(t, color.RGBA{0, 0, 255, 255}),
+	}
+	var stream bytes.Buffer
+	// A frame cut short after its APP1 segment, and one cut short in its scan
+	// data, each directly followed by the next frame.
+	stream.Write(broken[:12])
+	stream.Write(frames[0])
+	stream.Write(broken[:len(broken)-10])
+	stream.Write(frames[1])
+
+	fr := NewFrameReader(&stream)
+	for i, want := range frames {
+		got, err := fr.ReadFrame()
+		if err != nil {
+			t.Fatalf("ReadFrame %d returned error: %v", i, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("ReadFrame %d returned %d bytes, want %d", i, len(got), len(want))
+		}
+	}
+	if _, err := fr.ReadFrame(); err != io.EOF {
+		t.Errorf("ReadFrame at the end returned %v, want %v", err, io.EOF)
+	}
+}
+
+func TestCommandServices_LivePreview(t *testing.T) {
+	setup()
+	defer teardown()
+	client.apiLevel = 2
+
+	frame := testJPEG(t, color.RGBA{0, 255, 0, 255})
+	mux.HandleFunc(commandsExecuteURL, func(w http.ResponseWriter, r *http.Request) {
+		v := new(CommandRequest)
+		json.NewDecoder(r.Body).Decode(v)
+		if got, want := *v.Name, "camera.getLivePreview"; got != want {
+			t.Errorf("Request name is %v, want %v", got, want)
+		}
+		w.Header().Set("Content-Type", "multipart/x-mixed-replace; boundary=---osclivepreview---")
+		for {
+			fmt.Fprint(w, "---osclivepreview---\r\nContent-type: image/jpeg\r\n\r\n")
+			if _, err := w.Write(frame); err != nil {
+				return
+			}
+			w.(http.Flusher).Flush()
+			select {
+			case <-r.Context().Done():
+				return
+			default:
+			}
+		}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, _, err := client.Command.LivePreview(ctx)
+	if err != nil {
+		t.Fatalf("Command.LivePreview returned error: %v", err)
+	}
+	defer stream.Close()
+
+	for i := 0; i < 3; i++ {
+		f, err := stream.Next()
+		if err != nil {
+			t.Fatalf("PreviewStream.Next returned error: %v", err)
+		}
+		if !bytes.Equal(f, frame) {
+			t.Errorf("PreviewStream.Next returned %d bytes, want %d", len(f), len(frame))
+		}
+	}
+
+	cancel()
+	for {
+		if _, err := stream.Next(); err != nil {
+			if err != context.Canceled {
+				t.Errorf("PreviewStream.Next after cancel returned %v, want %v", err, context.Canceled)
+			}
+			break
+		}
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright (c) 2017 "Shun Yokota" All rights reserved
+//
+// Part of the source code is adapted from https://github.com/google/go-github
+// Copyright 2013 The go-github AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package theta
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// IntervalPlan describes an interval or interval composite shooting.
+type IntervalPlan struct {
+	// Interval and Number are the seconds between shots and the number of
+	// shots of an interval shooting.
+	Interval int
+	Number   int
+
+	// Composite selects interval composite shooting, which composites the
+	// shots into an image every CompositeOutputInterval seconds for
+	// CompositeTime seconds.
+	Composite               bool
+	CompositeTime           int
+	CompositeOutputInterval int
+}
+
+// IntervalShoot runs an interval or interval composite shooting: it sets the
+// options of plan, starts the capture, waits until the camera is idle again and
+// returns the files of the shooting, which share an interval or composite
+// shooting group ID. The capture state is checked every Client.PollInterval.
+//
+// If the files have no group IDs, as in Theta API v2.0, the plan.Number newest
+// files are returned, or only the latest file of a composite shooting, whose
+// number of files is not known. If ctx is done first, the capture is left
+// running; use StopCapture to stop it.
+func (c *Client) IntervalShoot(ctx context.Context, plan *IntervalPlan) ([]*Entries, error) {
+	options := new(Options)
+	kind := CaptureInterval
+	switch {
+	case plan.Composite:
+		kind = CaptureComposite
+		options.CompositeShootingTime = Int(plan.CompositeTime)
+		options.CompositeShootingOutputInterval = Int(plan.CompositeOutputInterval)
+	case plan.Number <= 0:
+		return nil, errors.New("interval shooting needs a number of shots")
+	case c.APILevel() == 1:
+		options.CaptureIntervalv20 = Int(plan.Interval)
+		options.CaptureNumberv20 = Int(plan.Number)
+	default:
+		options.CaptureInterval = Int(plan.Interval)
+		options.CaptureNumber = Int(plan.Number)
+	}
+	if _, _, err := c.Command.SetOptions(ctx, options); err != nil {
+		return nil, err
+	}
+	if _, err := c.Command.StartCapture(ctx, kind); err != nil {
+		return nil, err
+	}
+
+	state, err := c.waitIdle(ctx)
+	if err != nil {
+		return nil, err
+	}
+	latest := state.LatestFileURL
+	if latest == "" {
+		latest = state.LatestFileURI
+	}
+	groupID := func(e *Entries) *string { return e.IntervalCaptureGroupID }
+	if plan.Composite {
+		groupID = func(e *Entries) *string { return e.CompositeShootingGroupID }
+	}
+	return c.groupFiles(ctx, latest, groupID, plan.Number)
+}
+
+// BracketGroup represents the files shot together by an auto bracket.
+type BracketGroup struct {
+	ID    string // AutoBracketGroupID of the files
+	Files []*Entries
+}
+
+// BracketShoot takes an auto bracket with a shot for each of params, which
+// holds 2 to MaxBracketNumber exposures. It sets the camera to manual exposure
+// with the auto bracket, takes the picture, waits until the camera is idle
+// again, and returns the files of the bracket.
+func (c *Client) BracketShoot(ctx context.Context, params []BracketParameter) (*BracketGroup, error) {
+	bracket := NewBracket(params...)
+	if err := bracket.validate(); err != nil {
+		return nil, err
+	}
+	options := &Options{
+		CaptureMode:     String(CaptureModeImage),
+		ExposureProgram: Int(ExposureProgramManual),
+		AutoBracket:     bracket,
+	}
+	if _, _, err := c.Command.SetOptions(ctx, options); err != nil {
+		return nil, err
+	}
+	url, _, err := c.Command.TakePicture(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.waitIdle(ctx); err != nil {
+		return nil, err
+	}
+
+	groupID := func(e *Entries) *string { return e.AutoBracketGroupID }
+	files, err := c.groupFiles(ctx, url, groupID, len(params))
+	if err != nil {
+		return nil, err
+	}
+	group := &BracketGroup{Files: files}
+	if len(files) > 0 && files[0].AutoBracketGroupID != nil {
+		group.ID = *files[0].AutoBracketGroupID
+	}
+	return group, nil
+}
+
+// waitIdle polls the Theta state until no capture is running.
+func (c *Client) waitIdle(ctx context.Context) (*State, error) {
+	for {
+		state, _, err := c.State.Get(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if state.CaptureStatus == CaptureStatusIdle {
+			return state, nil
+		}
+		t := time.NewTimer(c.pollInterval())
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// groupFiles returns the files in the same group as the file at latest, found
+// with groupID. If the file has no group ID, the n newest files are returned
+// instead, since the camera lists the newest files first, or the file at latest
+// alone if n is 0.
+func (c *Client) groupFiles(ctx context.Context, latest string, groupID func(*Entries) *string, n int) ([]*Entries, error) {
+	var entries []*Entries
+	var id string
+	it := c.Command.Files(FileTypeImage)
+	for it.Next(ctx) {
+		e := it.Entry()
+		entries = append(entries, e)
+		if latest != "" && e.URL() == latest {
+			if g := groupID(e); g != nil {
+				id = *g
+			}
+		}
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+
+	if id == "" && n <= 0 {
+		for _, e := range entries {
+			if latest != "" && e.URL() == latest {
+				return []*Entries{e}, nil
+			}
+		}
+		return nil, errors.New("the files of the shooting are not found")
+	}
+	if id == "" {
+		if n > len(entries) {
+			n = len(entries)
+		}
+		return entries[:n], nil
+	}
+	var group []*Entries
+	for _, e := range entries {
+		if g := groupID(e); g != nil && *g == id {
+			group = append(group, e)
+		}
+	}
+	return group, nil
+}
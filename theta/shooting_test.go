@@ -0,0 +1,187 @@
+// Copyright (c) 2017 "Shun Yokota" All rights reserved
+//
+// Part of the source code is adapted from https://github.com/google/go-github
+// Copyright 2013 The go-github AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package theta
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestClient_IntervalShoot(t *testing.T) {
+	setup()
+	defer teardown()
+	client.apiLevel = 2
+	client.PollInterval = time.Millisecond
+
+	mux.HandleFunc(commandsExecuteURL, func(w http.ResponseWriter, r *http.Request) {
+		v := new(CommandRequest)
+		json.NewDecoder(r.Body).Decode(v)
+		switch *v.Name {
+		case "camera.setOptions":
+			o := v.Parameters.Options
+			if o.CaptureInterval != nil && (*o.CaptureInterval != 10 || *o.CaptureNumber != 2) {
+				t.Errorf("setOptions captureInterval and captureNumber are %v, %v, want 10, 2", *o.CaptureInterval, *o.CaptureNumber)
+			}
+			fmt.Fprint(w, `{"name":"camera.setOptions","state":"done"}`)
+		case "camera.startCapture":
+			fmt.Fprint(w, `{"name":"camera.startCapture","state":"done"}`)
+		case "camera.listFiles":
+			if d := v.Parameters.Detail; d != nil && !*d {
+				t.Errorf("listFiles _detail is false, want the group IDs")
+			}
+			fmt.Fprint(w, `{"name":"camera.listFiles","state":"done","results":{"entries":[
+				{"name":"R0010003.JPG","fileUrl":"http://192.168.1.1/files/R0010003.JPG","_intervalCaptureGroupId":"G2"},
+				{"name":"R0010002.JPG","fileUrl":"http://192.168.1.1/files/R0010002.JPG","_intervalCaptureGroupId":"G2"},
+				{"name":"R0010001.JPG","fileUrl":"http://192.168.1.1/files/R0010001.JPG","_intervalCaptureGroupId":"G1"}
+			],"totalEntries":3}}`)
+		}
+	})
+	polls := 0
+	mux.HandleFunc(stateURL, func(w http.ResponseWriter, r *http.Request) {
+		polls++
+		status := CaptureStatusShooting
+		if polls > 2 {
+			status = CaptureStatusIdle
+		}
+		fmt.Fprintf(w, `{"fingerprint":"FIG_%d","state":{"_captureStatus":%q,"_latestFileUrl":"http://192.168.1.1/files/R0010003.JPG"}}`, polls, status)
+	})
+
+	files, err := client.IntervalShoot(context.Background(), &IntervalPlan{Interval: 10, Number: 2})
+	if err != nil {
+		t.Fatalf("IntervalShoot returned error: %v", err)
+	}
+	var names []string
+	for _, e := range files {
+		names = append(names, *e.Name)
+	}
+	if want := []string{"R0010003.JPG", "R0010002.JPG"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("IntervalShoot returned %v, want %v", names, want)
+	}
+}
+
+func TestClient_IntervalShoot_composite(t *testing.T) {
+	for _, tt := range []struct {
+		groupIDs bool
+		want     []string
+	}{
+		{true, []string{"R0010003.JPG", "R0010002.JPG"}},
+		{false, []string{"R0010003.JPG"}},
+	} {
+		setup()
+		client.apiLevel = 2
+		client.PollInterval = time.Millisecond
+
+		mux.HandleFunc(commandsExecuteURL, func(w http.ResponseWriter, r *http.Request) {
+			v := new(CommandRequest)
+			json.NewDecoder(r.Body).Decode(v)
+			switch *v.Name {
+			case "camera.setOptions":
+				o := v.Parameters.Options
+				if o.CompositeShootingTime != nil && *o.CompositeShootingTime != 600 {
+					t.Errorf("setOptions _compositeShootingTime is %v, want 600", o.CompositeShootingTime)
+				}
+				fmt.Fprint(w, `{"name":"camera.setOptions","state":"done"}`)
+			case "camera.startCapture":
+				if got, want := *v.Parameters.Mode, CaptureComposite; got != want {
+					t.Errorf("startCapture _mode is %v, want %v", got, want)
+				}
+				fmt.Fprint(w, `{"name":"camera.startCapture","state":"done"}`)
+			case "camera.listFiles":
+				group := `,"_compositeShootingGroupId":"C1"`
+				if !tt.groupIDs {
+					group = ""
+				}
+				fmt.Fprintf(w, `{"name":"camera.listFiles","state":"done","results":{"entries":[
+					{"name":"R0010003.JPG","fileUrl":"http://192.168.1.1/files/R0010003.JPG"%[1]s},
+					{"name":"R0010002.JPG","fileUrl":"http://192.168.1.1/files/R0010002.JPG"%[1]s},
+					{"name":"R0010001.JPG","fileUrl":"http://192.168.1.1/files/R0010001.JPG"}
+				],"totalEntries":3}}`, group)
+			}
+		})
+		mux.HandleFunc(stateURL, func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, `{"fingerprint":"FIG_0001","state":{"_captureStatus":"idle","_latestFileUrl":"http://192.168.1.1/files/R0010003.JPG"}}`)
+		})
+
+		plan := &IntervalPlan{Composite: true, CompositeTime: 600, CompositeOutputInterval: 60}
+		files, err := client.IntervalShoot(context.Background(), plan)
+		if err != nil {
+			t.Fatalf("IntervalShoot returned error: %v", err)
+		}
+		var names []string
+		for _, e := range files {
+			names = append(names, *e.Name)
+		}
+		if !reflect.DeepEqual(names, tt.want) {
+			t.Errorf("IntervalShoot with group IDs %v returned %v, want %v", tt.groupIDs, names, tt.want)
+		}
+		teardown()
+	}
+}
+
+func TestClient_BracketShoot(t *testing.T) {
+	setup()
+	defer teardown()
+	client.apiLevel = 2
+
+	params := []BracketParameter{
+		{ShutterSpeed: 0.004, ISO: 100, ColorTemperature: 5100},
+		{ShutterSpeed: 0.016, ISO: 100, ColorTemperature: 5100},
+		{ShutterSpeed: 0.0625, ISO: 100, ColorTemperature: 5100},
+	}
+	mux.HandleFunc(commandsExecuteURL, func(w http.ResponseWriter, r *http.Request) {
+		v := new(CommandRequest)
+		json.NewDecoder(r.Body).Decode(v)
+		switch *v.Name {
+		case "camera.setOptions":
+			want := &Bracket{BracketNumber: 3, BracketParameters: params}
+			if got := v.Parameters.Options.AutoBracket; !reflect.DeepEqual(got, want) {
+				t.Errorf("setOptions _autoBracket is %v, want %v", got, want)
+			}
+			fmt.Fprint(w, `{"name":"camera.setOptions","state":"done"}`)
+		case "camera.takePicture":
+			fmt.Fprint(w, `{"name":"camera.takePicture","state":"done","results":{"fileUrl":"http://192.168.1.1/files/R0010003.JPG"}}`)
+		case "camera.listFiles":
+			if d := v.Parameters.Detail; d != nil && !*d {
+				t.Errorf("listFiles _detail is false, want the group IDs")
+			}
+			fmt.Fprint(w, `{"name":"camera.listFiles","state":"done","results":{"entries":[
+				{"name":"R0010003.JPG","fileUrl":"http://192.168.1.1/files/R0010003.JPG","_autoBracketGroupId":"B1"},
+				{"name":"R0010002.JPG","fileUrl":"http://192.168.1.1/files/R0010002.JPG","_autoBracketGroupId":"B1"},
+				{"name":"R0010001.JPG","fileUrl":"http://192.168.1.1/files/R0010001.JPG","_autoBracketGroupId":"B1"},
+				{"name":"R0010000.JPG","fileUrl":"http://192.168.1.1/files/R0010000.JPG"}
+			],"totalEntries":4}}`)
+		}
+	})
+	mux.HandleFunc(stateURL, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"fingerprint":"FIG_0001","state":{"_captureStatus":"idle"}}`)
+	})
+
+	group, err := client.BracketShoot(context.Background(), params)
+	if err != nil {
+		t.Fatalf("BracketShoot returned error: %v", err)
+	}
+	if got, want := group.ID, "B1"; got != want {
+		t.Errorf("BracketShoot group ID is %v, want %v", got, want)
+	}
+	if got, want := len(group.Files), 3; got != want {
+		t.Errorf("BracketShoot returned %v files, want %v", got, want)
+	}
+}
+
+func TestClient_BracketShoot_tooMany(t *testing.T) {
+	params := make([]BracketParameter, MaxBracketNumber+1)
+	if _, err := NewClient(nil).BracketShoot(context.Background(), params); err == nil {
+		t.Errorf("BracketShoot with %d shots returned no error", len(params))
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright (c) 2017 "Shun Yokota" All rights reserved
+//
+// Part of the source code is adapted from https://github.com/google/go-github
+// Copyright 2013 The go-github AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package theta
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Capture statuses reported in State.CaptureStatus.
+const (
+	CaptureStatusIdle              = "idle"
+	CaptureStatusShooting          = "shooting"
+	CaptureStatusSelfTimerCounting = "self-timer countdown"
+	CaptureStatusBracketShooting   = "bracket shooting"
+	CaptureStatusConverting        = "converting"
+	CaptureStatusTimeShifting      = "timeShift shooting"
+)
+
+// State represents a Theta state.
+type State struct {
+	Fingerprint    string   `json:"-"`
+	BatteryLevel   float64  `json:"batteryLevel"`
+	BatteryState   string   `json:"_batteryState"`
+	StorageURI     string   `json:"storageUri"`
+	CaptureStatus  string   `json:"_captureStatus"`
+	RecordedTime   int      `json:"_recordedTime"`
+	RecordableTime int      `json:"_recordableTime"`
+	LatestFileURL  string   `json:"_latestFileUrl"`
+	APIVersion     int      `json:"_apiVersion"`
+	CameraError    []string `json:"_cameraError"`
+	Function       string   `json:"_function"`
+
+	// Deprecated in Theta API v2.1 (OSC v2.0).
+	SessionID     string `json:"sessionId"`
+	LatestFileURI string `json:"_latestFileUri"`
+}
+
+func (s State) String() string {
+	return Stringify(s)
+}
+
+// StateServices handles communication with the state of connected Theta.
+type StateServices service
+
+// Get the Theta state.
+func (s *StateServices) Get(ctx context.Context) (*State, *http.Response, error) {
+	req, err := s.client.NewRequest("POST", stateURL, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	body := new(struct {
+		Fingerprint string `json:"fingerprint"`
+		State       *State `json:"state"`
+	})
+	resp, err := s.client.Do(ctx, req, body)
+	if err != nil {
+		return nil, resp, err
+	}
+	state := body.State
+	if state == nil {
+		state = new(State)
+	}
+	state.Fingerprint = body.Fingerprint
+	return state, resp, nil
+}
+
+// Updates represents a response of checkForUpdates.
+type Updates struct {
+	StateFingerprint string `json:"stateFingerprint"`
+	ThrottleTimeout  int    `json:"throttleTimeout"` // seconds to wait before checking again
+}
+
+func (u Updates) String() string {
+	return Stringify(u)
+}
+
+// CheckForUpdates gets the current fingerprint of the Theta state, which
+// differs from fingerprint when the state has changed. A waitTimeout in seconds
+// other than 0 lets the camera hold the request until the state changes or
+// waitTimeout elapses.
+func (s *StateServices) CheckForUpdates(ctx context.Context, fingerprint string, waitTimeout int) (*Updates, *http.Response, error) {
+	body := struct {
+		StateFingerprint string `json:"stateFingerprint"`
+		WaitTimeout      int    `json:"waitTimeout,omitempty"`
+	}{fingerprint, waitTimeout}
+	req, err := s.client.NewRequest("POST", checkForUpdatesURL, body)
+	if err != nil {
+		return nil, nil, err
+	}
+	updates := new(Updates)
+	resp, err := s.client.Do(ctx, req, updates)
+	if err != nil {
+		return nil, resp, err
+	}
+	return updates, resp, nil
+}
+
+// StateEvent is sent by WatchState when the Theta state has changed, or with
+// Err set when the state could not be checked.
+type StateEvent struct {
+	State *State
+	Err   error
+}
+
+// WatchState watches the Theta state and returns a channel of its changes. The
+// current state is sent first. Afterwards the fingerprint is checked every
+// interval, or less often if the camera asks to throttle, and the full state is
+// fetched only when the fingerprint has changed. Client.PollInterval is used if
+// interval is not positive. Errors are sent as events and watching continues.
+// The channel is closed when ctx is done.
+func (c *Client) WatchState(ctx context.Context, interval time.Duration) <-chan *StateEvent {
+	if interval <= 0 {
+		interval = c.pollInterval()
+	}
+	events := make(chan *StateEvent)
+	go func() {
+		defer close(events)
+		send := func(e *StateEvent) bool {
+			select {
+			case events <- e:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		fingerprint := ""
+		delay := time.Duration(0)
+		for {
+			if delay > 0 {
+				t := time.NewTimer(delay)
+				select {
+				case <-ctx.Done():
+					t.Stop()
+					return
+				case <-t.C:
+				}
+			}
+			delay = interval
+
+			if fingerprint != "" {
+				updates, _, err := c.State.CheckForUpdates(ctx, fingerprint, 0)
+				if err != nil {
+					if ctx.Err() != nil || !send(&StateEvent{Err: err}) {
+						return
+					}
+					continue
+				}
+				if throttle := time.Duration(updates.ThrottleTimeout) * time.Second; throttle > delay {
+					delay = throttle
+				}
+				if updates.StateFingerprint == fingerprint {
+					continue
+				}
+			}
+
+			state, _, err := c.State.Get(ctx)
+			if err != nil {
+				if ctx.Err() != nil || !send(&StateEvent{Err: err}) {
+					return
+				}
+				continue
+			}
+			fingerprint = state.Fingerprint
+			if !send(&StateEvent{State: state}) {
+				return
+			}
+		}
+	}()
+	return events
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2017 "Shun Yokota" All rights reserved
+//
+// Part of the source code is adapted from https://github.com/google/go-github
+// Copyright 2013 The go-github AUTHORS. All rights reserved.
+//
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package theta
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStateServices_Get(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc(stateURL, func(w http.ResponseWriter, r *http.Request) {
+		if got, want := r.Method, "POST"; got != want {
+			t.Errorf("Request method: %v, want %v", got, want)
+		}
+		fmt.Fprint(w, `{"fingerprint":"FIG_0003","state":{"batteryLevel":0.67,"_batteryState":"charging",
+			"storageUri":"http://192.168.1.1/files/abcde/","_captureStatus":"idle","_apiVersion":2,
+			"_cameraError":["COMPASS_CALIBRATION"],"_function":"normal"}}`)
+	})
+
+	state, _, err := client.State.Get(context.Background())
+	if err != nil {
+		t.Fatalf("State.Get returned error: %v", err)
+	}
+	want := &State{
+		Fingerprint:   "FIG_0003",
+		BatteryLevel:  0.67,
+		BatteryState:  "charging",
+		StorageURI:    "http://192.168.1.1/files/abcde/",
+		CaptureStatus: CaptureStatusIdle,
+		APIVersion:    2,
+		CameraError:   []string{"COMPASS_CALIBRATION"},
+		Function:      "normal",
+	}
+	if !reflect.DeepEqual(state, want) {
+		t.Errorf("State.Get returned %v, want %v", state, want)
+	}
+}
+
+func TestClient_WatchState(t *testing.T) {
+	setup()
+	defer teardown()
+
+	var gets, checks int
+	mux.HandleFunc(stateURL, func(w http.ResponseWriter, r *http.Request) {
+		gets++
+		fmt.Fprintf(w, `{"fingerprint":"FIG_%d","state":{"batteryLevel":%v}}`, gets, 1.0-0.1*float64(gets))
+	})
+	mux.HandleFunc(checkForUpdatesURL, func(w http.ResponseWriter, r *http.Request) {
+		checks++
+		v := new(Updates)
+		json.NewDecoder(r.Body).Decode(v)
+		if checks < 3 {
+			// no change
+			fmt.Fprintf(w, `{"stateFingerprint":%q,"throttleTimeout":0}`, v.StateFingerprint)
+			return
+		}
+		fmt.Fprint(w, `{"stateFingerprint":"FIG_NEW","throttleTimeout":0}`)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := client.WatchState(ctx, time.Millisecond)
+
+	for i, want := range []string{"FIG_1", "FIG_2"} {
+		e := <-events
+		if e.Err != nil {
+			t.Fatalf("WatchState event %d has error: %v", i, e.Err)
+		}
+		if got := e.State.Fingerprint; got != want {
+			t.Errorf("WatchState event %d Fingerprint is %v, want %v", i, got, want)
+		}
+	}
+	if got, want := checks, 3; got != want {
+		t.Errorf("WatchState checked for updates %v times, want %v", got, want)
+	}
+
+	cancel()
+	for range events {
+	}
+}
+
+func TestClient_WatchState_defaultInterval(t *testing.T) {
+	setup()
+	defer teardown()
+	client.PollInterval = 20 * time.Millisecond
+
+	gets := 0
+	mux.HandleFunc(stateURL, func(w http.ResponseWriter, r *http.Request) {
+		gets++
+		fmt.Fprintf(w, `{"fingerprint":"FIG_%d","state":{}}`, gets)
+	})
+	mux.HandleFunc(checkForUpdatesURL, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"stateFingerprint":"FIG_NEW","throttleTimeout":0}`)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := client.WatchState(ctx, 0)
+
+	<-events
+	start := time.Now()
+	<-events
+	if got := time.Since(start); got < client.PollInterval {
+		t.Errorf("WatchState checked again after %v, want at least %v", got, client.PollInterval)
+	}
+
+	cancel()
+	for range events {
+	}
+}
@@ -17,16 +17,21 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"net/url"
+	"sync"
+	"time"
 )
 
 const (
-	defaultBaseURL  = "http://192.168.1.1"
-	defaultAPILevel = 1
+	defaultBaseURL      = "http://192.168.1.1"
+	defaultAPILevel     = 1
+	defaultPollInterval = time.Second
 
 	infoURL            = "/osc/info"
 	stateURL           = "/osc/state"
+	checkForUpdatesURL = "/osc/checkForUpdates"
 	commandsExecuteURL = "/osc/commands/execute"
 	commandStatusURL   = "/osc/commands/status"
 )
@@ -36,24 +41,87 @@ var (
 	ErrClientIsNil = errors.New("client is nil")
 )
 
+// Errors defined by the Open Spherical Camera API. An *ErrorResponse matches them
+// with errors.Is when it carries the same code.
+var (
+	ErrUnknownCommand          = &Error{Code: "unknownCommand"}
+	ErrDisabledCommand         = &Error{Code: "disabledCommand"}
+	ErrMissingParameter        = &Error{Code: "missingParameter"}
+	ErrInvalidParameterName    = &Error{Code: "invalidParameterName"}
+	ErrInvalidParameterValue   = &Error{Code: "invalidParameterValue"}
+	ErrInvalidSessionID        = &Error{Code: "invalidSessionId"}
+	ErrCameraInExclusiveUse    = &Error{Code: "cameraInExclusiveUse"}
+	ErrCorruptedFile           = &Error{Code: "corruptedFile"}
+	ErrPowerOffSequenceRunning = &Error{Code: "powerOffSequenceRunning"}
+	ErrServiceUnavailable      = &Error{Code: "serviceUnavailable"}
+	ErrUploadError             = &Error{Code: "uploadError"}
+)
+
 // Client manages communication with the THETA API.
 type Client struct { // adapted from https://github.com/google/go-github
 	client *http.Client // HTTP client used to communicate with the API.
 
 	BaseURL  *url.URL // URL for a API requests.
 	apiLevel int      // Theta API Level(1: v2.0, 2: v2.1).
+
+	// PollInterval is the interval at which the status of an in-progress
+	// command is polled. One second is used if it is not positive.
+	PollInterval time.Duration
+
+	// SupportedOptions, if set, holds the Support values reported by the camera
+	// with GetOptions. SetOptions validates options against them before
+	// sending a request.
+	SupportedOptions *Options
+	mu               sync.Mutex // guards sessionID and sessionTimeout
 	// sessionID of Theta API v2.0 (OSC v1.0). Deprecated in Theta API v2.1 (OSC v2.0).
-	sessionID string
+	sessionID      string
+	sessionTimeout time.Duration
+	capture        string // kind of the capture started by StartCapture
 
 	common  service
 	Info    *InfoServices
 	Command *CommandServices
+	State   *StateServices
 }
 
 type service struct {
 	client *Client
 }
 
+// session returns the session ID to be sent with commands, or nil if the client
+// speaks Theta API v2.1 (OSC v2.0), which has no session.
+func (c *Client) session() *string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.apiLevel != 1 {
+		return nil
+	}
+	return String(c.sessionID)
+}
+
+// APILevel returns the Theta API level the client speaks: 1 for v2.0
+// (OSC v1.0) and 2 for v2.1 (OSC v2.0).
+func (c *Client) APILevel() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.apiLevel
+}
+
+// pollInterval returns PollInterval, or defaultPollInterval if it is not
+// positive, so that the camera is never polled in a tight loop.
+func (c *Client) pollInterval() time.Duration {
+	if c.PollInterval <= 0 {
+		return defaultPollInterval
+	}
+	return c.PollInterval
+}
+
+func (c *Client) setAPILevel(level int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.apiLevel = level
+}
+
 // NewClient returns a new THETA API client.
 //
 // adapted from https://github.com/google/go-github
@@ -64,13 +132,15 @@ func NewClient(httpClient *http.Client) *Client {
 	baseURL, _ := url.Parse(defaultBaseURL)
 
 	c := &Client{
-		client:   httpClient,
-		BaseURL:  baseURL,
-		apiLevel: defaultAPILevel,
+		client:       httpClient,
+		BaseURL:      baseURL,
+		apiLevel:     defaultAPILevel,
+		PollInterval: defaultPollInterval,
 	}
 	c.common.client = c
 	c.Info = (*InfoServices)(&c.common)
 	c.Command = (*CommandServices)(&c.common)
+	c.State = (*StateServices)(&c.common)
 	//c.Info = (*InfoServices)s
 	return c
 }
@@ -97,7 +167,6 @@ func (c *Client) NewRequest(method, urlStr string, body interface{}) (*http.Requ
 		}
 	}
 
-	fmt.Println(uri.String())
 	req, err := http.NewRequest(method, uri.String(), buf)
 	if err != nil {
 		return nil, err
@@ -119,24 +188,15 @@ func (c *Client) NewRequest(method, urlStr string, body interface{}) (*http.Requ
 // The provided ctx must be non-nil. If it is canceled or times out,
 // ctx.Err() will be returned.
 func (c *Client) Do(ctx context.Context, req *http.Request, v interface{}) (*http.Response, error) { // adapted from https://github.com/google/go-github
-	req = req.WithContext(ctx)
-	resp, err := c.client.Do(req)
-	if err != nil {
-		select {
-		case <-ctx.Done():
-			return nil, ctx.Err()
-		default:
-		}
-		return nil, err
-	}
-
-	err = CheckResponse(resp)
+	resp, err := c.bareDo(ctx, req)
 	if err != nil {
 		return resp, err
 	}
+	defer resp.Body.Close()
+
 	if v != nil {
 		if w, ok := v.(io.Writer); ok {
-			io.Copy(w, resp.Body)
+			_, err = io.Copy(w, resp.Body)
 		} else {
 			err = json.NewDecoder(resp.Body).Decode(v)
 			if err == io.EOF {
@@ -144,57 +204,138 @@ func (c *Client) Do(ctx context.Context, req *http.Request, v interface{}) (*htt
 			}
 		}
 	}
+	if err != nil && ctx.Err() != nil {
+		return resp, ctx.Err()
+	}
 	return resp, err
 }
 
+// bareDo sends an THETA API request and returns the THETA API response with its
+// body left open for the caller to read and close. An error response is
+// returned as an error and its body is closed.
+func (c *Client) bareDo(ctx context.Context, req *http.Request) (*http.Response, error) {
+	req = req.WithContext(ctx)
+	resp, err := c.client.Do(req)
+	if err != nil {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		default:
+		}
+		return nil, err
+	}
+
+	if err := CheckResponse(resp); err != nil {
+		resp.Body.Close()
+		return resp, err
+	}
+	return resp, nil
+}
+
 // ErrorResponse reports one or more errors caused by an Theta API.
 type ErrorResponse struct {
-	Response *http.Response
-	Code     string `json:"code,omitempty"`
-	Message  string `json:"message,omitempty"`
+	Response *http.Response // HTTP response that caused this error
+	Code     string         `json:"code,omitempty"`
+	Message  string         `json:"message,omitempty"`
+}
+
+func (r *ErrorResponse) Error() string {
+	if r.Response == nil || r.Response.Request == nil {
+		return fmt.Sprintf("%v %v", r.Code, r.Message)
+	}
+	return fmt.Sprintf("%v %v: %d %v %v",
+		r.Response.Request.Method, r.Response.Request.URL,
+		r.Response.StatusCode, r.Code, r.Message)
 }
 
-// CheckResponse checks the API response for errors, and returns them ifpresent.
+// Is reports whether target is an OSC error with the same code as r, such as
+// ErrInvalidParameterValue.
+func (r *ErrorResponse) Is(target error) bool {
+	e, ok := target.(*Error)
+	return ok && e.Code == r.Code
+}
+
+// CheckResponse checks the API response for errors, and returns them if
+// present. A response is considered an error if it has a status code outside
+// the 200 range. The OSC error in the response body, if any, is stored in the
+// returned *ErrorResponse.
 func CheckResponse(r *http.Response) error { // adapted from https://github.com/google/go-github
-	return nil
+	if c := r.StatusCode; 200 <= c && c <= 299 {
+		return nil
+	}
+	errorResponse := &ErrorResponse{Response: r}
+	data, err := ioutil.ReadAll(r.Body)
+	if err == nil && data != nil {
+		body := new(CommandResponse)
+		if json.Unmarshal(data, body) == nil && body.Error != nil {
+			errorResponse.Code = body.Error.Code
+			errorResponse.Message = body.Error.Message
+		}
+	}
+	return errorResponse
 }
 
-// Begin the session and set API Level to the Theta.
+// Begin negotiates the API level with the Theta.
 // Camera API version should be set to v2.1 with clientVersion in order to use
 // RICOH THETA API v2.1, because the API version at the start point of the connection
 // via wireless LAN is v2.0.
-// When v2.1 is supported, API Version is set to v2.1 automatically. If you need to
+// When v2.1 is supported, API Version is set to v2.1 automatically and the session
+// used to set it is closed. Otherwise a session of v2.0 is started. If you need to
 // use v2.0, use StartSession and SetOptions to set to v2.0 manually.
+// The agreed level is available with APILevel.
 func Begin(ctx context.Context, c *Client) error {
 	if c == nil {
 		return ErrClientIsNil
 	}
-	session, resp, err := c.Command.StartSession(ctx)
+	info, _, err := c.Info.Get(ctx)
 	if err != nil {
 		return err
 	}
-	if session.Results != nil {
-		if session.Results.SessionID != nil {
-			fmt.Println(*session.Results.SessionID)
-			c.sessionID = *session.Results.SessionID
-		}
+	// A connection starts at the lowest level the camera speaks.
+	if level := minAPILevel(info.Endpoints.APILevel); level > 0 {
+		c.setAPILevel(level)
+	}
+	if c.APILevel() >= 2 {
+		return nil
+	}
+	if !info.SupportsAPILevel(2) {
+		_, _, err := c.Command.StartSession(ctx)
+		return err
+	}
+
+	// The camera keeps speaking v2.1 if a previous client has upgraded it.
+	if state, _, err := c.State.Get(ctx); err == nil && state.APIVersion >= 2 {
+		c.setAPILevel(2)
+		return nil
+	}
+
+	if _, _, err := c.Command.StartSession(ctx); err != nil {
+		return err
 	}
 	options := &Options{ClientVersion: Int(2)}
-	if cmd, _, err := c.Command.SetOptions(ctx, options); err == nil {
-		fmt.Println(*cmd.State)
-		if cmd.Error != nil {
-			fmt.Println(*cmd.Error)
-		}
+	if _, _, err := c.Command.SetOptions(ctx, options); err != nil {
 		return err
 	}
-	defer resp.Body.Close()
-	return nil
+	c.setAPILevel(2)
+
+	// The session of v2.0 is no longer used. Cameras which have already
+	// forgotten it may reject the request, which is fine.
+	_, _, err = c.Command.CloseSession(ctx)
+	c.setSession(nil)
+	if errors.Is(err, ErrUnknownCommand) || errors.Is(err, ErrDisabledCommand) || errors.Is(err, ErrInvalidSessionID) {
+		return nil
+	}
+	return err
 }
 
 // Bool is a helper routine that allocates a new bool value
 // to store v and returns a pointer to it.
 func Bool(v bool) *bool { return &v } // copied from https://github.com/google/go-github
 
+// Float64 is a helper routine that allocates a new float64 value
+// to store v and returns a pointer to it.
+func Float64(v float64) *float64 { return &v } // copied from https://github.com/google/go-github
+
 // Int is a helper routine that allocates a new int value
 // to store v and returns a pointer to it.
 func Int(v int) *int { return &v } // copied from https://github.com/google/go-github
@@ -9,12 +9,19 @@
 package theta
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 var (
@@ -118,3 +125,138 @@ func TestNewRequest_emptyBody(t *testing.T) {
 		t.Fatalf("constructed request contains a non-nil Body")
 	}
 }
+
+func TestCheckResponse(t *testing.T) {
+	res := &http.Response{
+		Request:    &http.Request{},
+		StatusCode: http.StatusBadRequest,
+		Body: ioutil.NopCloser(strings.NewReader(`{"name":"camera.setOptions","state":"error",
+			"error":{"code":"invalidParameterValue","message":"Parameter options contains unsupported value"}}`)),
+	}
+	err := CheckResponse(res).(*ErrorResponse)
+
+	if err == nil {
+		t.Errorf("Expected error response.")
+	}
+
+	want := &ErrorResponse{
+		Response: res,
+		Code:     "invalidParameterValue",
+		Message:  "Parameter options contains unsupported value",
+	}
+	if !reflect.DeepEqual(err, want) {
+		t.Errorf("Error = %#v, want %#v", err, want)
+	}
+	if !errors.Is(err, ErrInvalidParameterValue) {
+		t.Errorf("errors.Is(%v, ErrInvalidParameterValue) returned false, want true", err)
+	}
+	if errors.Is(err, ErrInvalidSessionID) {
+		t.Errorf("errors.Is(%v, ErrInvalidSessionID) returned true, want false", err)
+	}
+}
+
+// ensure that we properly handle API errors that do not contain a response body
+func TestCheckResponse_noBody(t *testing.T) {
+	res := &http.Response{
+		Request:    &http.Request{},
+		StatusCode: http.StatusServiceUnavailable,
+		Body:       ioutil.NopCloser(bytes.NewBufferString("")),
+	}
+	err := CheckResponse(res).(*ErrorResponse)
+
+	if err == nil {
+		t.Errorf("Expected error response.")
+	}
+
+	want := &ErrorResponse{
+		Response: res,
+	}
+	if !reflect.DeepEqual(err, want) {
+		t.Errorf("Error = %#v, want %#v", err, want)
+	}
+}
+
+func TestBegin(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc(infoURL, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"model":"RICOH THETA S","endpoints":{"httpPort":80,"apiLevel":[1,2]}}`)
+	})
+	mux.HandleFunc(stateURL, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"fingerprint":"FIG_0001","state":{"sessionId":"","_apiVersion":1}}`)
+	})
+	var names []string
+	mux.HandleFunc(commandsExecuteURL, func(w http.ResponseWriter, r *http.Request) {
+		v := new(CommandRequest)
+		json.NewDecoder(r.Body).Decode(v)
+		names = append(names, *v.Name)
+		switch *v.Name {
+		case "camera.startSession":
+			fmt.Fprint(w, `{"name":"camera.startSession","state":"done","results":{"sessionId":"SID_0001","timeout":180}}`)
+		case "camera.setOptions":
+			if v.Parameters.SessionID == nil || *v.Parameters.SessionID != "SID_0001" {
+				t.Errorf("setOptions sessionId is %v, want SID_0001", v.Parameters.SessionID)
+			}
+			if got := v.Parameters.Options.ClientVersion; got == nil || *got != 2 {
+				t.Errorf("setOptions clientVersion is %v, want 2", got)
+			}
+			fmt.Fprint(w, `{"name":"camera.setOptions","state":"done"}`)
+		case "camera.closeSession":
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"name":"camera.closeSession","state":"error","error":{"code":"unknownCommand","message":"v2.1"}}`)
+		}
+	})
+
+	if err := Begin(context.Background(), client); err != nil {
+		t.Fatalf("Begin returned error: %v", err)
+	}
+	want := []string{"camera.startSession", "camera.setOptions", "camera.closeSession"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("Begin sent %v, want %v", names, want)
+	}
+	if got, want := client.APILevel(), 2; got != want {
+		t.Errorf("Begin set APILevel to %v, want %v", got, want)
+	}
+	if id := client.session(); id != nil {
+		t.Errorf("session() returned %v after Begin, want nil", *id)
+	}
+}
+
+func TestBegin_v20(t *testing.T) {
+	setup()
+	defer teardown()
+
+	mux.HandleFunc(infoURL, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"model":"RICOH THETA m15","endpoints":{"httpPort":80,"apiLevel":[1]}}`)
+	})
+	mux.HandleFunc(commandsExecuteURL, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"name":"camera.startSession","state":"done","results":{"sessionId":"SID_0001","timeout":180}}`)
+	})
+
+	if err := Begin(context.Background(), client); err != nil {
+		t.Fatalf("Begin returned error: %v", err)
+	}
+	if got, want := client.APILevel(), 1; got != want {
+		t.Errorf("Begin set APILevel to %v, want %v", got, want)
+	}
+	if id := client.session(); id == nil || *id != "SID_0001" {
+		t.Errorf("session() returned %v after Begin, want SID_0001", id)
+	}
+}
+
+func TestClient_pollInterval(t *testing.T) {
+	c := NewClient(nil)
+	for _, tt := range []struct {
+		in, want time.Duration
+	}{
+		{time.Millisecond, time.Millisecond},
+		{0, defaultPollInterval},
+		{-time.Second, defaultPollInterval},
+	} {
+		c.PollInterval = tt.in
+		if got := c.pollInterval(); got != tt.want {
+			t.Errorf("pollInterval with PollInterval %v returned %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}